@@ -1,8 +1,20 @@
 package main
 
 import (
+	"time"
+
+	"github.com/google/uuid"
 	"github.com/kelseyhightower/envconfig"
 	"github.com/pkg/errors"
+
+	"apigateway/pkg/apigateway/infrastructure/logging"
+)
+
+const (
+	// restGatewayModeLoopback makes REST gateway dial grpc server by ServeGRPCAddress
+	restGatewayModeLoopback = "loopback"
+	// restGatewayModeInProcess makes REST gateway call grpc server through in-memory connection
+	restGatewayModeInProcess = "inprocess"
 )
 
 func parseEnv() (*config, error) {
@@ -10,14 +22,62 @@ func parseEnv() (*config, error) {
 	if err := envconfig.Process(appID, c); err != nil {
 		return nil, errors.Wrap(err, "failed to parse env")
 	}
+	if c.RESTGatewayMode != restGatewayModeLoopback && c.RESTGatewayMode != restGatewayModeInProcess {
+		return nil, errors.Errorf("unknown rest gateway mode %q", c.RESTGatewayMode)
+	}
 	return c, nil
 }
 
 type config struct {
 	ServeRESTAddress                 string `envconfig:"serve_rest_address" default:":8001"`
 	ServeGRPCAddress                 string `envconfig:"serve_grpc_address" default:":8002"`
+	RESTGatewayMode                  string `envconfig:"rest_gateway_mode" default:"loopback"`
+	GRPCReflectionEnabled            bool   `envconfig:"grpc_reflection_enabled" default:"false"`
 	ContentServiceGRPCAddress        string `envconfig:"content_service_grpc_address"`
 	UserServiceGRPCAddress           string `envconfig:"user_service_grpc_address"`
 	AuthenticationServiceGRPCAddress string `envconfig:"authentication_service_grpc_address"`
 	PlaylistServiceGRPCAddress       string `envconfig:"playlist_service_grpc_address"`
+
+	// ServeAddress enables single listener mode for grpc, gRPC-Web and REST instead of ServeRESTAddress and ServeGRPCAddress
+	ServeAddress          string   `envconfig:"serve_address"`
+	TLSCertFile           string   `envconfig:"tls_cert_file"`
+	TLSKeyFile            string   `envconfig:"tls_key_file" secret:"true"`
+	GRPCWebAllowedOrigins []string `envconfig:"grpc_web_allowed_origins"`
+
+	OpenAPIServerURL string `envconfig:"openapi_server_url"`
+	OpenAPIVersion   string `envconfig:"openapi_version" default:"2"`
+
+	TracingExporter     string  `envconfig:"tracing_exporter" default:"none"`
+	TracingOTLPEndpoint string  `envconfig:"tracing_otlp_endpoint" default:"localhost:4317"`
+	TracingOTLPInsecure bool    `envconfig:"tracing_otlp_insecure" default:"false"`
+	TracingFilePath     string  `envconfig:"tracing_file_path" default:"traces.jsonl"`
+	TracingSampleRatio  float64 `envconfig:"tracing_sample_ratio" default:"1"`
+
+	// AdminServeAddress serves debug endpoints and metrics, it must not be exposed publicly, empty address disables admin server
+	AdminServeAddress string        `envconfig:"admin_serve_address" default:"127.0.0.1:8003"`
+	LogLevel          logging.Level `envconfig:"log_level" default:"info"`
+
+	AccessLogSampleRatio   float64  `envconfig:"access_log_sample_ratio" default:"1"`
+	AccessLogExcludedPaths []string `envconfig:"access_log_excluded_paths" default:"/resilience/ready,/resilience/live"`
+
+	// LogRedactedFields are hidden in request logs in addition to transport.DefaultRedactedFields
+	LogRedactedFields []string `envconfig:"log_redacted_fields"`
+
+	// PageTokenSecret signs page tokens, it must be the same on all instances, so tokens are valid on any of them
+	PageTokenSecret string `envconfig:"page_token_secret" required:"true" secret:"true"`
+
+	// AuditLogFilePath is file of audit log of this instance, GetAuditLog returns records only of instance which answers
+	AuditLogFilePath string `envconfig:"audit_log_file_path" required:"true"`
+	// AuditAdminUserIDs are allowed to query audit log
+	AuditAdminUserIDs []uuid.UUID `envconfig:"audit_admin_user_ids"`
+	// AuditTrustedProxies are CIDRs or IPs of load balancers whose X-Forwarded-For is used as client IP of audit records and access log
+	AuditTrustedProxies []string `envconfig:"audit_trusted_proxies"`
+
+	// ReadinessRequiredBackends are service names of backends, e.g. playlistservice.PlayListService,
+	// whose connection failure makes gateway not ready. By default readiness depends only on gateway itself
+	ReadinessRequiredBackends []string `envconfig:"readiness_required_backends"`
+
+	ShutdownDrainPeriod time.Duration `envconfig:"shutdown_drain_period" default:"5s"`
+	GRPCStopTimeout     time.Duration `envconfig:"grpc_stop_timeout" default:"10s"`
+	RESTShutdownTimeout time.Duration `envconfig:"rest_shutdown_timeout" default:"10s"`
 }
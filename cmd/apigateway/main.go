@@ -14,22 +14,40 @@ import (
 	log "github.com/CuriosityMusicStreaming/ComponentsPool/pkg/app/logger"
 	jsonlog "github.com/CuriosityMusicStreaming/ComponentsPool/pkg/infrastructure/logger"
 	"github.com/CuriosityMusicStreaming/ComponentsPool/pkg/infrastructure/server"
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 
 	"apigateway/api/apigateway"
 	"apigateway/api/authenticationservice"
 	contentserviceapi "apigateway/api/contentservice"
 	playlistserviceapi "apigateway/api/playlistservice"
 	userserviceapi "apigateway/api/userservice"
+	"apigateway/pkg/apigateway/infrastructure/admin"
+	"apigateway/pkg/apigateway/infrastructure/audit"
 	"apigateway/pkg/apigateway/infrastructure/auth"
+	"apigateway/pkg/apigateway/infrastructure/health"
+	"apigateway/pkg/apigateway/infrastructure/interceptor"
+	"apigateway/pkg/apigateway/infrastructure/logging"
+	"apigateway/pkg/apigateway/infrastructure/metrics"
+	"apigateway/pkg/apigateway/infrastructure/tracing"
 	"apigateway/pkg/apigateway/infrastructure/transport"
 	"apigateway/pkg/apigateway/infrastructure/transport/apiserver"
+	"apigateway/pkg/apigateway/infrastructure/transport/openapi"
 )
 
 var appID = "UNKNOWN"
 
+const (
+	tracingShutdownTimeout = 5 * time.Second
+)
+
 func main() {
 	logger, err := initLogger()
 	if err != nil {
@@ -41,7 +59,12 @@ func main() {
 		logger.FatalError(err)
 	}
 
-	err = runService(config, logger)
+	levelLogger, err := logging.NewLevelLogger(logger, config.LogLevel)
+	if err != nil {
+		logger.FatalError(err)
+	}
+
+	err = runService(config, levelLogger)
 	if err == server.ErrStopped {
 		logger.Info("service is successfully stopped")
 	} else if err != nil {
@@ -49,71 +72,329 @@ func main() {
 	}
 }
 
-func runService(config *config, logger log.MainLogger) error {
+func runService(config *config, logger logging.LevelLogger) error {
+	shutdownTracing, err := tracing.InitTracerProvider(context.Background(), tracing.Config{
+		ServiceName:  appID,
+		Exporter:     config.TracingExporter,
+		OTLPEndpoint: config.TracingOTLPEndpoint,
+		OTLPInsecure: config.TracingOTLPInsecure,
+		FilePath:     config.TracingFilePath,
+		SampleRatio:  config.TracingSampleRatio,
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		if shutdownErr := shutdownTracing(ctx); shutdownErr != nil {
+			logger.Error(shutdownErr, "failed to flush traces")
+		}
+	}()
+
+	metricsRegistry, err := metrics.NewRegistry()
+	if err != nil {
+		return err
+	}
+
+	redactedFields := append(append([]string{}, transport.DefaultRedactedFields...), config.LogRedactedFields...)
+	redactor, err := transport.NewRedactor(redactedFields)
+	if err != nil {
+		return err
+	}
+
+	authenticationService := auth.NewAuthenticationService(auth.TypeBearer)
+	debugSessions := logging.NewDebugSessions()
+
+	connections, err := initBackendConnections(
+		config,
+		metricsRegistry,
+		transport.NewBackendLoggerClientInterceptor(logger, redactor, debugSessions, authenticationService),
+	)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := connections.close(); closeErr != nil {
+			logger.Error(closeErr, "failed to close backend connections")
+		}
+	}()
+
+	auditLog, err := audit.NewFileLog(config.AuditLogFilePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := auditLog.Close(); closeErr != nil {
+			logger.Error(closeErr, "failed to close audit log")
+		}
+	}()
+
+	trustedProxies, err := transport.ParseTrustedProxies(config.AuditTrustedProxies)
+	if err != nil {
+		return err
+	}
+
+	instance, err := os.Hostname()
+	if err != nil {
+		return errors.Wrap(err, "failed to get host name")
+	}
+
+	apiServer := initAPIServer(connections, authenticationService, auditLog, config.AuditAdminUserIDs, instance, []byte(config.PageTokenSecret))
+
+	healthServer := grpchealth.NewServer()
+	healthMonitor, err := health.NewMonitor(healthServer, connections.healthBackends(), config.ReadinessRequiredBackends, logger)
+	if err != nil {
+		return err
+	}
+
+	err = metricsRegistry.Register(metrics.NewConnectionStateCollector(connections.healthBackends()))
+	if err != nil {
+		return err
+	}
+
+	metricsInterceptor, err := metrics.NewServerInterceptor(metricsRegistry)
+	if err != nil {
+		return err
+	}
+
 	stopChan := make(chan struct{})
-	listenForKillSignal(stopChan)
+	listenForKillSignal(stopChan, healthMonitor, config.ShutdownDrainPeriod, logger)
 
 	serverHub := server.NewHub(stopChan)
 
-	apiServer, err := initAPIServer(config)
+	baseServer := grpc.NewServer(interceptor.ChainServer(
+		tracing.NewServerInterceptor(),
+		metricsInterceptor,
+		transport.NewLoggerServerInterceptor(logger, redactor),
+		audit.NewServerInterceptor(
+			auditLog,
+			audit.MutatingMethods(apigateway.File_apigateway_proto.Services().ByName("APIGateway")),
+			authenticationService,
+			trustedProxies,
+			logger,
+		),
+	)...)
+	apigateway.RegisterAPIGatewayServer(baseServer, apiServer)
+	healthpb.RegisterHealthServer(baseServer, healthServer)
+	if config.GRPCReflectionEnabled {
+		reflection.Register(baseServer)
+	}
+
+	ctx := context.Background()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go healthMonitor.Run(ctx)
+
+	if config.ServeAddress != "" {
+		err = addSingleListenerServer(ctx, config, serverHub, baseServer, healthMonitor, metricsRegistry, trustedProxies, logger)
+	} else {
+		err = addServers(ctx, config, serverHub, baseServer, healthMonitor, metricsRegistry, trustedProxies, logger)
+	}
 	if err != nil {
 		return err
 	}
 
-	baseServer := grpc.NewServer(grpc.UnaryInterceptor(transport.NewLoggerServerInterceptor(logger)))
-	apigateway.RegisterAPIGatewayServer(baseServer, apiServer)
+	// Admin server is added last, so it is stopped after public servers
+	if config.AdminServeAddress != "" {
+		serverHub.AddServer(transport.NewHTTPServer(
+			admin.NewHandler(admin.Config{
+				ServiceConfig: config,
+				Backends:      connections.healthBackends(),
+				Logger:        logger,
+				DebugSessions: debugSessions,
+				Metrics:       metrics.NewHandler(metricsRegistry),
+			}),
+			transport.HTTPServerConfig{
+				ServeAddress:    config.AdminServeAddress,
+				ShutdownTimeout: config.RESTShutdownTimeout,
+			},
+			logger,
+		))
+	}
 
-	serverHub.AddServer(server.NewGrpcServer(
+	return serverHub.Run()
+}
+
+// addServers serves grpc and REST on separate listeners
+func addServers(
+	ctx context.Context,
+	config *config,
+	serverHub server.Hub,
+	baseServer *grpc.Server,
+	healthMonitor health.Monitor,
+	metricsRegistry *prometheus.Registry,
+	trustedProxies transport.TrustedProxies,
+	logger log.Logger,
+) error {
+	serverHub.AddServer(transport.NewGrpcServer(
 		baseServer,
-		server.GrpcServerConfig{ServeAddress: config.ServeGRPCAddress},
+		transport.GrpcServerConfig{
+			ServeAddress: config.ServeGRPCAddress,
+			StopTimeout:  config.GRPCStopTimeout,
+		},
 		logger,
 	))
 
-	ctx := context.Background()
-	ctx, cancel := context.WithCancel(ctx)
-	var httpServer *http.Server
-
-	serverHub.AddServer(&server.FuncServer{
-		ServeImpl: func() error {
-			grpcGatewayMux := runtime.NewServeMux()
-			opts := []grpc.DialOption{grpc.WithInsecure()}
-			err := apigateway.RegisterAPIGatewayHandlerFromEndpoint(ctx, grpcGatewayMux, config.ServeGRPCAddress, opts)
-			if err != nil {
-				return err
-			}
-
-			router := mux.NewRouter()
-			router.PathPrefix("/api/").Handler(grpcGatewayMux)
-
-			router.HandleFunc("/resilience/ready", func(w http.ResponseWriter, _ *http.Request) {
-				w.WriteHeader(http.StatusOK)
-				_, _ = io.WriteString(w, http.StatusText(http.StatusOK))
-			}).Methods(http.MethodGet)
-
-			httpServer = &http.Server{
-				Handler:      router,
-				Addr:         config.ServeRESTAddress,
-				WriteTimeout: 15 * time.Second,
-				ReadTimeout:  15 * time.Second,
-			}
-
-			logger.Info("REST server started")
-			return httpServer.ListenAndServe()
+	grpcGatewayEndpoint := config.ServeGRPCAddress
+	grpcGatewayOpts := []grpc.DialOption{grpc.WithInsecure()}
+	if config.RESTGatewayMode == restGatewayModeInProcess {
+		inProcessServer := transport.NewInProcessGrpcServer(baseServer, config.GRPCStopTimeout, logger)
+		serverHub.AddServer(inProcessServer)
+
+		grpcGatewayEndpoint = transport.InProcessEndpoint
+		grpcGatewayOpts = inProcessServer.DialOptions()
+	}
+
+	router, err := newRESTRouter(ctx, config, grpcGatewayEndpoint, grpcGatewayOpts, healthMonitor, metricsRegistry, trustedProxies, logger)
+	if err != nil {
+		return err
+	}
+
+	serverHub.AddServer(transport.NewHTTPServer(
+		router,
+		transport.HTTPServerConfig{
+			ServeAddress:    config.ServeRESTAddress,
+			ShutdownTimeout: config.RESTShutdownTimeout,
 		},
-		StopImpl: func() error {
-			cancel()
-			return httpServer.Shutdown(context.Background())
+		logger,
+	))
+	return nil
+}
+
+// addSingleListenerServer serves grpc, gRPC-Web and REST on config.ServeAddress, REST gateway always calls grpc server in-process
+func addSingleListenerServer(
+	ctx context.Context,
+	config *config,
+	serverHub server.Hub,
+	baseServer *grpc.Server,
+	healthMonitor health.Monitor,
+	metricsRegistry *prometheus.Registry,
+	trustedProxies transport.TrustedProxies,
+	logger log.Logger,
+) error {
+	inProcessServer := transport.NewInProcessGrpcServer(baseServer, config.GRPCStopTimeout, logger)
+
+	router, err := newRESTRouter(ctx, config, transport.InProcessEndpoint, inProcessServer.DialOptions(), healthMonitor, metricsRegistry, trustedProxies, logger)
+	if err != nil {
+		return err
+	}
+
+	// Multiplex server stops base server, so it is added first and in-process server does not gracefully stop it
+	serverHub.AddServer(transport.NewMultiplexServer(
+		baseServer,
+		router,
+		transport.MultiplexServerConfig{
+			ServeAddress:    config.ServeAddress,
+			ShutdownTimeout: config.RESTShutdownTimeout,
+			AllowedOrigins:  config.GRPCWebAllowedOrigins,
+			TLSCertFile:     config.TLSCertFile,
+			TLSKeyFile:      config.TLSKeyFile,
 		},
+		logger,
+	))
+	serverHub.AddServer(inProcessServer)
+	return nil
+}
+
+func newRESTRouter(
+	ctx context.Context,
+	config *config,
+	grpcGatewayEndpoint string,
+	grpcGatewayOpts []grpc.DialOption,
+	healthMonitor health.Monitor,
+	metricsRegistry *prometheus.Registry,
+	trustedProxies transport.TrustedProxies,
+	logger log.Logger,
+) (*mux.Router, error) {
+	grpcGatewayMux := runtime.NewServeMux(
+		runtime.WithIncomingHeaderMatcher(transport.IncomingHeaderMatcher),
+		runtime.WithOutgoingHeaderMatcher(transport.OutgoingHeaderMatcher),
+		// Responses of google.api.HttpBody are written as is, e.g. exported playlists
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.HTTPBodyMarshaler{
+			Marshaler: &runtime.JSONPb{OrigName: true},
+		}),
+	)
+	grpcGatewayOpts = append(grpcGatewayOpts, interceptor.ChainClient(tracing.NewClientInterceptor())...)
+	err := apigateway.RegisterAPIGatewayHandlerFromEndpoint(ctx, grpcGatewayMux, grpcGatewayEndpoint, grpcGatewayOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	descriptorHandler, err := transport.NewFileDescriptorSetHandler(apigateway.File_apigateway_proto)
+	if err != nil {
+		return nil, err
+	}
+
+	specHandler, err := openapi.NewSpecHandler(openapi.Config{
+		ServerURL: config.OpenAPIServerURL,
+		AuthType:  auth.TypeBearer,
+		Version:   config.OpenAPIVersion,
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	return serverHub.Run()
+	docsHandler, err := openapi.NewDocsHandler("/api/docs/", "/api/openapi.json")
+	if err != nil {
+		return nil, err
+	}
+
+	metricsMiddleware, err := metrics.NewHTTPMiddleware(
+		metricsRegistry,
+		metrics.NewGatewayRoutes(apigateway.File_apigateway_proto.Services().ByName("APIGateway")),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	accessLogMiddleware := transport.NewAccessLogMiddleware(logger, transport.AccessLogConfig{
+		SampleRatio:    config.AccessLogSampleRatio,
+		ExcludedPaths:  config.AccessLogExcludedPaths,
+		TrustedProxies: trustedProxies,
+	})
+
+	router := mux.NewRouter()
+	router.Use(tracing.NewHTTPMiddleware(), metricsMiddleware, accessLogMiddleware)
+	router.Handle("/api/descriptor", descriptorHandler).Methods(http.MethodGet)
+	router.Handle("/api/openapi.json", specHandler).Methods(http.MethodGet)
+	router.PathPrefix("/api/docs").Handler(docsHandler).Methods(http.MethodGet)
+	router.PathPrefix("/api/").Handler(transport.FieldsQueryParameterHandler(grpcGatewayMux))
+
+	router.HandleFunc("/resilience/ready", func(w http.ResponseWriter, _ *http.Request) {
+		if !healthMonitor.Serving() {
+			writeStatus(w, http.StatusServiceUnavailable)
+			return
+		}
+		writeStatus(w, http.StatusOK)
+	}).Methods(http.MethodGet)
+
+	router.HandleFunc("/resilience/live", func(w http.ResponseWriter, _ *http.Request) {
+		writeStatus(w, http.StatusOK)
+	}).Methods(http.MethodGet)
+
+	return router, nil
 }
 
-func listenForKillSignal(stopChan chan<- struct{}) {
+// listenForKillSignal marks service as not ready and waits drainPeriod before stopping servers
+// so load balancers stop routing new requests. Second signal skips draining
+func listenForKillSignal(stopChan chan<- struct{}, healthMonitor health.Monitor, drainPeriod time.Duration, logger log.Logger) {
 	go func() {
 		ch := make(chan os.Signal, 1)
 		signal.Notify(ch, syscall.SIGTERM, syscall.SIGINT)
 		<-ch
+
+		healthMonitor.Shutdown()
+		logger.WithField("drainPeriod", drainPeriod.String()).Info("draining connections")
+
+		timer := time.NewTimer(drainPeriod)
+		select {
+		case <-timer.C:
+		case <-ch:
+			timer.Stop()
+		}
+
 		stopChan <- struct{}{}
 	}()
 }
@@ -122,73 +403,103 @@ func initLogger() (log.MainLogger, error) {
 	return jsonlog.NewLogger(&jsonlog.Config{AppName: appID}), nil
 }
 
-func initAPIServer(config *config) (apigateway.APIGatewayServer, error) {
-	opts := []grpc.DialOption{
-		grpc.WithInsecure(),
-	}
+func writeStatus(w http.ResponseWriter, statusCode int) {
+	w.WriteHeader(statusCode)
+	_, _ = io.WriteString(w, http.StatusText(statusCode))
+}
 
-	contentServiceClient, err := initContentServiceClient(opts, config)
-	if err != nil {
-		return nil, err
-	}
+type backendConnections struct {
+	contentService        *grpc.ClientConn
+	userService           *grpc.ClientConn
+	playlistService       *grpc.ClientConn
+	authenticationService *grpc.ClientConn
+}
 
-	userServiceClient, err := initUserServiceClient(opts, config)
-	if err != nil {
-		return nil, err
+func (connections *backendConnections) healthBackends() []health.Backend {
+	return []health.Backend{
+		{ServiceName: "contentservice.ContentService", Conn: connections.contentService},
+		{ServiceName: "userservice.UserService", Conn: connections.userService},
+		{ServiceName: "playlistservice.PlayListService", Conn: connections.playlistService},
+		{ServiceName: "authenticationservice.AuthenticationService", Conn: connections.authenticationService},
 	}
+}
 
-	playlistServiceClient, err := initPlaylistServiceClient(opts, config)
-	if err != nil {
-		return nil, err
+func (connections *backendConnections) close() error {
+	var err error
+	for _, conn := range []*grpc.ClientConn{
+		connections.contentService,
+		connections.userService,
+		connections.playlistService,
+		connections.authenticationService,
+	} {
+		if closeErr := conn.Close(); err == nil && closeErr != nil {
+			err = closeErr
+		}
 	}
+	return err
+}
 
-	authenticationServiceClient, err := initAuthenticationServiceClient(opts, config)
+func initBackendConnections(
+	config *config,
+	metricsRegistry *prometheus.Registry,
+	loggerInterceptor interceptor.Client,
+) (*backendConnections, error) {
+	metricsInterceptor, err := metrics.NewClientInterceptor(metricsRegistry)
 	if err != nil {
 		return nil, err
 	}
 
-	return apiserver.NewAPIGatewayServer(
-		contentServiceClient,
-		userServiceClient,
-		playlistServiceClient,
-		authenticationServiceClient,
-		auth.NewAuthenticationService(auth.TypeBearer),
-		commonauth.NewUserDescriptorSerializer(),
-	), nil
-}
+	opts := append(
+		[]grpc.DialOption{grpc.WithInsecure()},
+		interceptor.ChainClient(tracing.NewClientInterceptor(), metricsInterceptor, loggerInterceptor)...,
+	)
 
-func initContentServiceClient(commonOpts []grpc.DialOption, config *config) (contentserviceapi.ContentServiceClient, error) {
-	conn, err := grpc.Dial(config.ContentServiceGRPCAddress, commonOpts...)
+	contentServiceConn, err := grpc.Dial(config.ContentServiceGRPCAddress, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	return contentserviceapi.NewContentServiceClient(conn), nil
-}
-
-func initUserServiceClient(commonOpts []grpc.DialOption, config *config) (userserviceapi.UserServiceClient, error) {
-	conn, err := grpc.Dial(config.UserServiceGRPCAddress, commonOpts...)
+	userServiceConn, err := grpc.Dial(config.UserServiceGRPCAddress, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	return userserviceapi.NewUserServiceClient(conn), nil
-}
-
-func initPlaylistServiceClient(commonOpts []grpc.DialOption, config *config) (playlistserviceapi.PlayListServiceClient, error) {
-	conn, err := grpc.Dial(config.PlaylistServiceGRPCAddress, commonOpts...)
+	playlistServiceConn, err := grpc.Dial(config.PlaylistServiceGRPCAddress, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	return playlistserviceapi.NewPlayListServiceClient(conn), nil
-}
-
-func initAuthenticationServiceClient(commonOpts []grpc.DialOption, config *config) (authenticationservice.AuthenticationServiceClient, error) {
-	conn, err := grpc.Dial(config.AuthenticationServiceGRPCAddress, commonOpts...)
+	authenticationServiceConn, err := grpc.Dial(config.AuthenticationServiceGRPCAddress, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	return authenticationservice.NewAuthenticationServiceClient(conn), nil
+	return &backendConnections{
+		contentService:        contentServiceConn,
+		userService:           userServiceConn,
+		playlistService:       playlistServiceConn,
+		authenticationService: authenticationServiceConn,
+	}, nil
+}
+
+func initAPIServer(
+	connections *backendConnections,
+	authenticationService auth.AuthenticationService,
+	auditLog audit.Log,
+	auditAdminUserIDs []uuid.UUID,
+	instance string,
+	pageTokenKey []byte,
+) apigateway.APIGatewayServer {
+	return apiserver.NewAPIGatewayServer(
+		contentserviceapi.NewContentServiceClient(connections.contentService),
+		userserviceapi.NewUserServiceClient(connections.userService),
+		playlistserviceapi.NewPlayListServiceClient(connections.playlistService),
+		authenticationservice.NewAuthenticationServiceClient(connections.authenticationService),
+		authenticationService,
+		commonauth.NewUserDescriptorSerializer(),
+		auditLog,
+		auditAdminUserIDs,
+		instance,
+		pageTokenKey,
+	)
 }
@@ -0,0 +1,175 @@
+package admin
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"reflect"
+	runtimepprof "runtime/pprof"
+	"time"
+
+	log "github.com/CuriosityMusicStreaming/ComponentsPool/pkg/app/logger"
+	"github.com/gorilla/mux"
+
+	"apigateway/pkg/apigateway/infrastructure/health"
+	"apigateway/pkg/apigateway/infrastructure/logging"
+)
+
+const (
+	secretTag   = "secret"
+	maskedValue = "***"
+)
+
+type Config struct {
+	// ServiceConfig is shown with fields tagged `secret:"true"` masked
+	ServiceConfig interface{}
+	Backends      []health.Backend
+	Logger        logging.LevelLogger
+	DebugSessions logging.DebugSessions
+	// Metrics is served on /metrics, metrics are not exposed on public listeners
+	Metrics http.Handler
+}
+
+// NewHandler returns handler of debug endpoints, it must be served only on private address
+func NewHandler(config Config) http.Handler {
+	router := mux.NewRouter()
+
+	router.Handle("/metrics", config.Metrics).Methods(http.MethodGet)
+	router.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	router.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	router.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	router.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	router.PathPrefix("/debug/pprof/").HandlerFunc(pprof.Index)
+	router.Handle("/debug/vars", expvar.Handler()).Methods(http.MethodGet)
+
+	router.HandleFunc("/debug/goroutines", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_ = runtimepprof.Lookup("goroutine").WriteTo(w, 2)
+	}).Methods(http.MethodGet)
+
+	router.HandleFunc("/debug/config", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, maskSecrets(config.ServiceConfig))
+	}).Methods(http.MethodGet)
+
+	router.HandleFunc("/debug/backends", func(w http.ResponseWriter, _ *http.Request) {
+		states := make(map[string]string, len(config.Backends))
+		for _, backend := range config.Backends {
+			states[backend.ServiceName] = backend.Conn.GetState().String()
+		}
+		writeJSON(w, http.StatusOK, states)
+	}).Methods(http.MethodGet)
+
+	router.Handle("/debug/loglevel", newLogLevelHandler(config.Logger)).Methods(http.MethodGet, http.MethodPut)
+
+	router.HandleFunc("/debug/sessions", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, config.DebugSessions.List())
+	}).Methods(http.MethodGet)
+	router.Handle("/debug/sessions", newStartDebugSessionHandler(config.DebugSessions, config.Logger)).Methods(http.MethodPost)
+	router.HandleFunc("/debug/sessions/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if !config.DebugSessions.Stop(mux.Vars(r)["id"]) {
+			writeJSON(w, http.StatusNotFound, errorResponse{Error: "debug session not found"})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}).Methods(http.MethodDelete)
+
+	return router
+}
+
+type logLevel struct {
+	Level logging.Level `json:"level"`
+}
+
+// newLogLevelHandler returns current level on GET and sets it from {"level": "..."} on PUT, levels are debug, info and error
+func newLogLevelHandler(logger logging.LevelLogger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			var body logLevel
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+				return
+			}
+			if err := logger.SetLevel(body.Level); err != nil {
+				writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+				return
+			}
+			logger.WithField("level", body.Level).Info("log level changed")
+		}
+		writeJSON(w, http.StatusOK, logLevel{Level: logger.Level()})
+	})
+}
+
+type startDebugSessionRequest struct {
+	UserID     string `json:"userID"`
+	ActivityID string `json:"activityID"`
+	// Duration is in time.ParseDuration format, e.g. 30m
+	Duration string `json:"duration"`
+}
+
+// newStartDebugSessionHandler starts session enabling debug logging of user or activity for given duration
+func newStartDebugSessionHandler(debugSessions logging.DebugSessions, logger log.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body startDebugSessionRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+			return
+		}
+		duration, err := time.ParseDuration(body.Duration)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+			return
+		}
+
+		session, err := debugSessions.Start(logging.DebugSession{
+			UserID:     body.UserID,
+			ActivityID: body.ActivityID,
+			ExpiresAt:  time.Now().Add(duration),
+		})
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+			return
+		}
+
+		logger.WithFields(log.Fields{
+			"sessionID":  session.ID,
+			"userID":     session.UserID,
+			"activityID": session.ActivityID,
+			"expiresAt":  session.ExpiresAt,
+		}).Info("debug session started")
+		writeJSON(w, http.StatusCreated, session)
+	})
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// maskSecrets converts struct to map by field names replacing non-zero secret fields
+func maskSecrets(config interface{}) interface{} {
+	value := reflect.Indirect(reflect.ValueOf(config))
+	if value.Kind() != reflect.Struct {
+		return config
+	}
+
+	result := make(map[string]interface{}, value.NumField())
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		fieldValue := value.Field(i)
+		if field.Tag.Get(secretTag) == "true" && !fieldValue.IsZero() {
+			result[field.Name] = maskedValue
+			continue
+		}
+		result[field.Name] = fieldValue.Interface()
+	}
+	return result
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,184 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const maxRecordSize = 1024 * 1024
+
+var ErrBrokenChain = errors.New("audit log hash chain is broken")
+
+// NewFileLog returns log stored in JSONL file, each record contains hash of previous one,
+// so removed or modified records break the chain. Chain of existing file is verified on open,
+// last record left half-written by crash is removed
+func NewFileLog(path string) (Log, error) {
+	lastHash, size, err := verifyFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open audit log %s", path)
+	}
+
+	err = file.Truncate(size)
+	if err != nil {
+		_ = file.Close()
+		return nil, errors.Wrapf(err, "failed to truncate audit log %s", path)
+	}
+
+	return &fileLog{
+		path:     path,
+		file:     file,
+		lastHash: lastHash,
+		size:     size,
+	}, nil
+}
+
+type fileLog struct {
+	mutex    sync.Mutex
+	path     string
+	file     *os.File
+	lastHash string
+	// size is length of written records, Query reads only them
+	size int64
+}
+
+func (l *fileLog) Append(record Record) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	record.Timestamp = record.Timestamp.UTC()
+	record.PrevHash = l.lastHash
+	hash, err := recordHash(record)
+	if err != nil {
+		return err
+	}
+	record.Hash = hash
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	n, err := l.file.Write(append(line, '\n'))
+	if err != nil {
+		// Partially written record would break the chain for next records
+		_ = l.file.Truncate(l.size)
+		return errors.Wrap(err, "failed to write audit record")
+	}
+
+	l.lastHash = hash
+	l.size += int64(n)
+	return nil
+}
+
+// Query reads file without blocking Append, records appended after start of query are not returned
+func (l *fileLog) Query(filter Filter) ([]Record, error) {
+	l.mutex.Lock()
+	size := l.size
+	l.mutex.Unlock()
+
+	var records []Record
+	_, err := readFile(l.path, size, func(record Record) error {
+		if filter.match(record) {
+			records = append(records, record)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+	if filter.Limit > 0 && len(records) > filter.Limit {
+		records = records[:filter.Limit]
+	}
+	return records, nil
+}
+
+func (l *fileLog) Close() error {
+	return l.file.Close()
+}
+
+// verifyFile checks hash chain of existing file and returns hash of last record and length of complete records
+func verifyFile(path string) (string, int64, error) {
+	lastHash := ""
+	line := 0
+	size, err := readFile(path, -1, func(record Record) error {
+		line++
+		hash, err := recordHash(record)
+		if err != nil {
+			return err
+		}
+		if record.PrevHash != lastHash || record.Hash != hash {
+			return errors.Wrapf(ErrBrokenChain, "record %d of %s", line, path)
+		}
+		lastHash = hash
+		return nil
+	})
+	if os.IsNotExist(errors.Cause(err)) {
+		return "", 0, nil
+	}
+	return lastHash, size, err
+}
+
+// readFile reads records from first size bytes of file or from whole file when size is negative.
+// It returns length of complete records, line without trailing newline is left by interrupted write and is skipped
+func readFile(path string, size int64, f func(record Record) error) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if size >= 0 {
+		r = io.LimitReader(file, size)
+	}
+	reader := bufio.NewReaderSize(r, maxRecordSize)
+
+	var offset int64
+	for {
+		line, err := reader.ReadSlice('\n')
+		if err == io.EOF {
+			return offset, nil
+		}
+		if err == bufio.ErrBufferFull {
+			return offset, errors.Errorf("audit record at offset %d of %s is longer than %d bytes", offset, path, maxRecordSize)
+		}
+		if err != nil {
+			return offset, errors.Wrapf(err, "failed to read audit log %s", path)
+		}
+
+		var record Record
+		if err := json.Unmarshal(line, &record); err != nil {
+			return offset, errors.Wrapf(err, "failed to parse audit log %s", path)
+		}
+		if err := f(record); err != nil {
+			return offset, err
+		}
+		offset += int64(len(line))
+	}
+}
+
+// recordHash hashes record with empty Hash field, PrevHash links it with previous record
+func recordHash(record Record) (string, error) {
+	record.Hash = ""
+	data, err := json.Marshal(record)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
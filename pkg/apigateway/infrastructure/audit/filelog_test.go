@@ -0,0 +1,197 @@
+package audit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestFileLogChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditLog := openFileLog(t, path)
+	appendRecords(t, auditLog, "first", "second")
+	closeFileLog(t, auditLog)
+
+	auditLog = openFileLog(t, path)
+	appendRecords(t, auditLog, "third")
+
+	records, err := auditLog.Query(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want 3", len(records))
+	}
+	for i, method := range []string{"third", "second", "first"} {
+		if records[i].Method != method {
+			t.Errorf("record %d has method %q, want %q", i, records[i].Method, method)
+		}
+	}
+	if records[2].PrevHash != "" || records[1].PrevHash != records[2].Hash || records[0].PrevHash != records[1].Hash {
+		t.Error("records are not linked by hashes")
+	}
+	closeFileLog(t, auditLog)
+
+	openFileLog(t, path)
+}
+
+func TestFileLogTamperDetection(t *testing.T) {
+	testCases := []struct {
+		name   string
+		tamper func(lines [][]byte) [][]byte
+	}{
+		{
+			name: "modified record",
+			tamper: func(lines [][]byte) [][]byte {
+				lines[1] = bytes.Replace(lines[1], []byte(`"second"`), []byte(`"changed"`), 1)
+				return lines
+			},
+		},
+		{
+			name: "removed record",
+			tamper: func(lines [][]byte) [][]byte {
+				return append(lines[:1], lines[2:]...)
+			},
+		},
+		{
+			name: "reordered records",
+			tamper: func(lines [][]byte) [][]byte {
+				lines[0], lines[1] = lines[1], lines[0]
+				return lines
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "audit.jsonl")
+			auditLog := openFileLog(t, path)
+			appendRecords(t, auditLog, "first", "second", "third")
+			closeFileLog(t, auditLog)
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := bytes.SplitAfter(data, []byte("\n"))
+			err = os.WriteFile(path, bytes.Join(testCase.tamper(lines[:3]), nil), 0600)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = NewFileLog(path)
+			if errors.Cause(err) != ErrBrokenChain {
+				t.Fatalf("got error %v, want %v", err, ErrBrokenChain)
+			}
+		})
+	}
+}
+
+func TestFileLogHalfWrittenRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditLog := openFileLog(t, path)
+	appendRecords(t, auditLog, "first")
+	closeFileLog(t, auditLog)
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = file.WriteString(`{"timestamp":"2021-`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	auditLog = openFileLog(t, path)
+	appendRecords(t, auditLog, "second")
+	closeFileLog(t, auditLog)
+
+	auditLog = openFileLog(t, path)
+	records, err := auditLog.Query(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].Method != "second" || records[1].Method != "first" {
+		t.Fatalf("got records %+v, want second and first", records)
+	}
+}
+
+func TestFileLogQuery(t *testing.T) {
+	auditLog := openFileLog(t, filepath.Join(t.TempDir(), "audit.jsonl"))
+	for _, record := range []Record{
+		{Method: "add", ActorUserID: "u1", TargetIDs: []string{"playlistID=p1"}},
+		{Method: "remove", ActorUserID: "u2", TargetIDs: []string{"playlistID=p1"}},
+		{Method: "rename", ActorUserID: "u1", TargetIDs: []string{"playlistID=p2"}},
+	} {
+		if err := auditLog.Append(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		filter  Filter
+		methods []string
+	}{
+		{filter: Filter{}, methods: []string{"rename", "remove", "add"}},
+		{filter: Filter{UserID: "u1"}, methods: []string{"rename", "add"}},
+		{filter: Filter{ResourceID: "p1"}, methods: []string{"remove", "add"}},
+		{filter: Filter{UserID: "u1", ResourceID: "p1"}, methods: []string{"add"}},
+		{filter: Filter{Limit: 1}, methods: []string{"rename"}},
+	}
+	for _, testCase := range testCases {
+		records, err := auditLog.Query(testCase.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var methods []string
+		for _, record := range records {
+			methods = append(methods, record.Method)
+		}
+		if len(methods) != len(testCase.methods) {
+			t.Errorf("filter %+v: got %v, want %v", testCase.filter, methods, testCase.methods)
+			continue
+		}
+		for i := range methods {
+			if methods[i] != testCase.methods[i] {
+				t.Errorf("filter %+v: got %v, want %v", testCase.filter, methods, testCase.methods)
+				break
+			}
+		}
+	}
+}
+
+func openFileLog(t *testing.T, path string) Log {
+	t.Helper()
+	auditLog, err := NewFileLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = auditLog.Close()
+	})
+	return auditLog
+}
+
+func closeFileLog(t *testing.T, auditLog Log) {
+	t.Helper()
+	if err := auditLog.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func appendRecords(t *testing.T, auditLog Log, methods ...string) {
+	t.Helper()
+	for _, method := range methods {
+		err := auditLog.Append(Record{Timestamp: time.Now(), Method: method, Outcome: OutcomeOK})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
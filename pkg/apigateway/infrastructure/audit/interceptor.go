@@ -0,0 +1,206 @@
+package audit
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	log "github.com/CuriosityMusicStreaming/ComponentsPool/pkg/app/logger"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"apigateway/pkg/apigateway/infrastructure/auth"
+	"apigateway/pkg/apigateway/infrastructure/interceptor"
+	"apigateway/pkg/apigateway/infrastructure/transport"
+)
+
+const (
+	authorizationHeaderName = "authorization"
+	forwardedForHeaderName  = "x-forwarded-for"
+)
+
+// MutatingMethods returns full names of service methods mapped to non GET HTTP rule
+func MutatingMethods(service protoreflect.ServiceDescriptor) []string {
+	var result []string
+	methods := service.Methods()
+	for i := 0; i < methods.Len(); i++ {
+		method := methods.Get(i)
+		rule, ok := proto.GetExtension(method.Options(), annotations.E_Http).(*annotations.HttpRule)
+		if ok && rule != nil && rule.GetGet() != "" {
+			continue
+		}
+		result = append(result, "/"+string(service.FullName())+"/"+string(method.Name()))
+	}
+	return result
+}
+
+// NewServerInterceptor returns interceptor that records calls of given methods to audit log.
+// Failure to write record is logged and does not fail the call since it is already done
+func NewServerInterceptor(
+	auditLog Log,
+	methods []string,
+	authenticationService auth.AuthenticationService,
+	trustedProxies transport.TrustedProxies,
+	logger log.Logger,
+) interceptor.Server {
+	audited := make(map[string]struct{}, len(methods))
+	for _, method := range methods {
+		audited[method] = struct{}{}
+	}
+
+	r := &recorder{
+		auditLog:              auditLog,
+		authenticationService: authenticationService,
+		trustedProxies:        trustedProxies,
+		logger:                logger,
+	}
+
+	return interceptor.Server{
+		Unary: func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			if _, ok := audited[info.FullMethod]; !ok {
+				return handler(ctx, req)
+			}
+
+			resp, err := handler(ctx, req)
+
+			targetIDs := collectTargetIDs(req, nil)
+			if err == nil {
+				targetIDs = collectTargetIDs(resp, targetIDs)
+			}
+			r.record(ctx, info.FullMethod, targetIDs, err)
+			return resp, err
+		},
+		Stream: func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if _, ok := audited[info.FullMethod]; !ok {
+				return handler(srv, stream)
+			}
+
+			err := handler(srv, stream)
+
+			r.record(stream.Context(), info.FullMethod, nil, err)
+			return err
+		},
+	}
+}
+
+type recorder struct {
+	auditLog              Log
+	authenticationService auth.AuthenticationService
+	trustedProxies        transport.TrustedProxies
+	logger                log.Logger
+}
+
+func (r *recorder) record(ctx context.Context, method string, targetIDs []string, callErr error) {
+	record := Record{
+		Timestamp:   time.Now(),
+		ActorUserID: r.actorUserID(ctx),
+		Method:      method,
+		TargetIDs:   targetIDs,
+		Outcome:     OutcomeOK,
+		ClientIP:    clientIP(ctx, r.trustedProxies),
+	}
+	if callErr != nil {
+		record.Outcome = status.Code(callErr).String()
+	}
+	if activityID, ok := transport.ActivityIDFromContext(ctx); ok {
+		record.ActivityID = activityID.String()
+	}
+
+	if err := r.auditLog.Append(record); err != nil {
+		r.logger.WithField("method", method).Error(err, "failed to write audit record")
+	}
+}
+
+func (r *recorder) actorUserID(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	headers := md.Get(authorizationHeaderName)
+	if len(headers) == 0 {
+		return ""
+	}
+	userDescriptor, err := r.authenticationService.ReceiveUserID(headers[0])
+	if err != nil {
+		return ""
+	}
+	return userDescriptor.UserID.String()
+}
+
+// clientIP trusts X-Forwarded-For of REST gateway of this instance, which appends address of its client to it
+func clientIP(ctx context.Context, trustedProxies transport.TrustedProxies) string {
+	address, local := peerAddress(ctx)
+	md, _ := metadata.FromIncomingContext(ctx)
+	return trustedProxies.ClientIP(address, local, md.Get(forwardedForHeaderName))
+}
+
+// peerAddress returns host of peer, local is set for loopback and non-network peers, e.g. in-process REST gateway
+func peerAddress(ctx context.Context) (address string, local bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "", false
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String(), true
+	}
+	ip := net.ParseIP(host)
+	return host, ip != nil && ip.IsLoopback()
+}
+
+// collectTargetIDs appends string fields of message and its nested messages named like ID or IDs,
+// e.g. playlistID, contentIDs and playlistItemID of batch results
+func collectTargetIDs(msg interface{}, targetIDs []string) []string {
+	protoMsg, ok := msg.(proto.Message)
+	if !ok {
+		return targetIDs
+	}
+	return appendTargetIDs(protoMsg.ProtoReflect(), targetIDs)
+}
+
+func appendTargetIDs(msg protoreflect.Message, targetIDs []string) []string {
+	msg.Range(func(fd protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		if fd.IsMap() {
+			return true
+		}
+
+		switch fd.Kind() {
+		case protoreflect.MessageKind:
+			if !fd.IsList() {
+				targetIDs = appendTargetIDs(value.Message(), targetIDs)
+				return true
+			}
+			list := value.List()
+			for i := 0; i < list.Len(); i++ {
+				targetIDs = appendTargetIDs(list.Get(i).Message(), targetIDs)
+			}
+		case protoreflect.StringKind:
+			name := string(fd.Name())
+			lowerName := strings.ToLower(name)
+			if !strings.HasSuffix(lowerName, "id") && !strings.HasSuffix(lowerName, "ids") {
+				return true
+			}
+			if !fd.IsList() {
+				targetIDs = appendTargetID(targetIDs, name, value.String())
+				return true
+			}
+			list := value.List()
+			for i := 0; i < list.Len(); i++ {
+				targetIDs = appendTargetID(targetIDs, name, list.Get(i).String())
+			}
+		}
+		return true
+	})
+	return targetIDs
+}
+
+// appendTargetID skips empty IDs, e.g. playlistItemID of failed batch item
+func appendTargetID(targetIDs []string, name, id string) []string {
+	if id == "" {
+		return targetIDs
+	}
+	return append(targetIDs, name+"="+id)
+}
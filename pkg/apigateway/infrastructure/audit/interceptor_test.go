@@ -0,0 +1,146 @@
+package audit
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+	"testing"
+
+	log "github.com/CuriosityMusicStreaming/ComponentsPool/pkg/app/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"apigateway/api/apigateway"
+	"apigateway/pkg/apigateway/infrastructure/auth"
+	"apigateway/pkg/apigateway/infrastructure/transport"
+)
+
+type inProcessAddr struct{}
+
+func (inProcessAddr) Network() string { return "bufconn" }
+func (inProcessAddr) String() string  { return "bufconn" }
+
+func TestClientIP(t *testing.T) {
+	trustedProxies, err := transport.ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name         string
+		peer         net.Addr
+		forwardedFor []string
+		want         string
+	}{
+		{
+			name:         "untrusted peer",
+			peer:         &net.TCPAddr{IP: net.ParseIP("203.0.113.7"), Port: 5000},
+			forwardedFor: []string{"198.51.100.1"},
+			want:         "203.0.113.7",
+		},
+		{
+			name:         "in-process REST gateway",
+			peer:         inProcessAddr{},
+			forwardedFor: []string{"198.51.100.1, 203.0.113.7"},
+			want:         "203.0.113.7",
+		},
+		{
+			name:         "loopback REST gateway behind trusted proxies",
+			peer:         &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 5000},
+			forwardedFor: []string{"198.51.100.1, 203.0.113.7, 10.1.2.3", "192.168.1.1"},
+			want:         "203.0.113.7",
+		},
+		{
+			name: "loopback without forwarded for",
+			peer: &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 5000},
+			want: "127.0.0.1",
+		},
+		{
+			name:         "trusted proxy",
+			peer:         &net.TCPAddr{IP: net.ParseIP("10.0.0.5"), Port: 5000},
+			forwardedFor: []string{"198.51.100.1, 203.0.113.7"},
+			want:         "203.0.113.7",
+		},
+		{
+			name:         "only trusted hops",
+			peer:         &net.TCPAddr{IP: net.ParseIP("10.0.0.5"), Port: 5000},
+			forwardedFor: []string{"10.0.0.6"},
+			want:         "10.0.0.6",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: testCase.peer})
+			md := metadata.MD{}
+			for _, value := range testCase.forwardedFor {
+				md.Append(forwardedForHeaderName, value)
+			}
+			ctx = metadata.NewIncomingContext(ctx, md)
+
+			if got := clientIP(ctx, trustedProxies); got != testCase.want {
+				t.Errorf("got %q, want %q", got, testCase.want)
+			}
+		})
+	}
+}
+
+type memoryLog struct {
+	records []Record
+}
+
+func (l *memoryLog) Append(record Record) error {
+	l.records = append(l.records, record)
+	return nil
+}
+
+func (l *memoryLog) Query(Filter) ([]Record, error) {
+	return l.records, nil
+}
+
+func (l *memoryLog) Close() error {
+	return nil
+}
+
+type nopLogger struct{}
+
+func (l nopLogger) WithField(string, interface{}) log.Logger { return l }
+func (l nopLogger) WithFields(log.Fields) log.Logger         { return l }
+func (nopLogger) Info(...interface{})                        {}
+func (nopLogger) Error(error, ...interface{})                {}
+
+func TestServerInterceptorRecordsBatchTargets(t *testing.T) {
+	const method = "/apigateway.APIGateway/BatchAddToPlaylist"
+	auditLog := &memoryLog{}
+	serverInterceptor := NewServerInterceptor(auditLog, []string{method}, auth.NewAuthenticationService(auth.TypeBearer), nil, nopLogger{})
+
+	req := &apigateway.BatchAddToPlaylistRequest{
+		PlaylistID: "p1",
+		ContentIDs: []string{"c1", "c2"},
+	}
+	resp := &apigateway.BatchAddToPlaylistResponse{
+		Results: []*apigateway.BatchPlaylistItemResult{
+			{Target: "c1", PlaylistItemID: "i1"},
+			{Target: "c2", Status: apigateway.PlaylistUpdateStatus_Failed},
+		},
+	}
+	_, err := serverInterceptor.Unary(context.Background(), req, &grpc.UnaryServerInfo{FullMethod: method},
+		func(context.Context, interface{}) (interface{}, error) {
+			return resp, nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(auditLog.records) != 1 {
+		t.Fatalf("got %d records, want 1", len(auditLog.records))
+	}
+	got := auditLog.records[0].TargetIDs
+	sort.Strings(got)
+	want := []string{"contentIDs=c1", "contentIDs=c2", "playlistID=p1", "playlistItemID=i1"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("got target IDs %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,55 @@
+package audit
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	OutcomeOK = "OK"
+)
+
+// Record describes single call of mutating method
+type Record struct {
+	Timestamp   time.Time `json:"timestamp"`
+	ActorUserID string    `json:"actorUserID,omitempty"`
+	Method      string    `json:"method"`
+	// TargetIDs are IDs passed in request or returned in response in form <field>=<id>
+	TargetIDs  []string `json:"targetIDs,omitempty"`
+	Outcome    string   `json:"outcome"`
+	ClientIP   string   `json:"clientIP,omitempty"`
+	ActivityID string   `json:"activityID,omitempty"`
+	PrevHash   string   `json:"prevHash"`
+	Hash       string   `json:"hash"`
+}
+
+// Filter selects records by actor and target, empty fields match any record
+type Filter struct {
+	UserID     string
+	ResourceID string
+	Limit      int
+}
+
+// Log is append-only storage of audit records
+type Log interface {
+	// Append fills hash fields of record and stores it
+	Append(record Record) error
+	// Query returns records matching filter, newest first
+	Query(filter Filter) ([]Record, error)
+	Close() error
+}
+
+func (f Filter) match(record Record) bool {
+	if f.UserID != "" && record.ActorUserID != f.UserID {
+		return false
+	}
+	if f.ResourceID == "" {
+		return true
+	}
+	for _, targetID := range record.TargetIDs {
+		if targetID == f.ResourceID || strings.HasSuffix(targetID, "="+f.ResourceID) {
+			return true
+		}
+	}
+	return false
+}
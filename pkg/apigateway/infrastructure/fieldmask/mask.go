@@ -0,0 +1,106 @@
+package fieldmask
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+var ErrInvalidPath = errors.New("invalid field mask path")
+
+// Mask selects fields of message by paths like "name" or "playlistItems.contentID",
+// paths may go through repeated fields. Empty mask selects all fields
+type Mask map[string]Mask
+
+// New validates paths against fields of message
+func New(message proto.Message, paths []string) (Mask, error) {
+	mask := Mask{}
+	for _, path := range paths {
+		if err := mask.add(message.ProtoReflect().Descriptor(), path); err != nil {
+			return nil, err
+		}
+	}
+	return mask, nil
+}
+
+// Includes reports whether top level field is selected
+func (m Mask) Includes(field string) bool {
+	if len(m) == 0 {
+		return true
+	}
+	_, ok := m[field]
+	return ok
+}
+
+// Sub returns mask of nested message field, empty if field is selected as whole
+func (m Mask) Sub(field string) Mask {
+	return m[field]
+}
+
+// Prune clears fields of message which are not selected
+func (m Mask) Prune(message proto.Message) {
+	if len(m) == 0 {
+		return
+	}
+	m.prune(message.ProtoReflect())
+}
+
+func (m Mask) prune(message protoreflect.Message) {
+	var cleared []protoreflect.FieldDescriptor
+	message.Range(func(field protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		sub, ok := m[string(field.Name())]
+		switch {
+		case !ok:
+			cleared = append(cleared, field)
+		case len(sub) == 0 || field.Message() == nil || field.IsMap():
+		case field.IsList():
+			list := value.List()
+			for i := 0; i < list.Len(); i++ {
+				sub.prune(list.Get(i).Message())
+			}
+		default:
+			sub.prune(value.Message())
+		}
+		return true
+	})
+	for _, field := range cleared {
+		message.Clear(field)
+	}
+}
+
+func (m Mask) add(descriptor protoreflect.MessageDescriptor, path string) error {
+	current := m
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		if descriptor == nil {
+			return errors.Wrapf(ErrInvalidPath, "%q is not a message field", strings.Join(names[:i], "."))
+		}
+		field := descriptor.Fields().ByName(protoreflect.Name(name))
+		if field == nil {
+			return errors.Wrapf(ErrInvalidPath, "unknown field %q", strings.Join(names[:i+1], "."))
+		}
+
+		next, ok := current[name]
+		if ok && len(next) == 0 {
+			// Field is already selected as whole
+			return nil
+		}
+		if i == len(names)-1 {
+			current[name] = nil
+			return nil
+		}
+		if !ok {
+			next = Mask{}
+			current[name] = next
+		}
+
+		current = next
+		descriptor = nil
+		if !field.IsMap() {
+			descriptor = field.Message()
+		}
+	}
+	return nil
+}
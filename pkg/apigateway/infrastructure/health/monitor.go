@@ -0,0 +1,146 @@
+package health
+
+import (
+	"context"
+	"sync"
+
+	log "github.com/CuriosityMusicStreaming/ComponentsPool/pkg/app/logger"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	APIGatewayServiceName = "apigateway.APIGateway"
+)
+
+type Backend struct {
+	ServiceName string
+	Conn        *grpc.ClientConn
+}
+
+// Monitor reports connectivity state of backend connections under their service names.
+// Gateway is serving until Shutdown unless some of required backends are not serving
+type Monitor interface {
+	Run(ctx context.Context)
+	Serving() bool
+	Shutdown()
+}
+
+// NewMonitor fails when required backend is not one of backends
+func NewMonitor(healthServer *health.Server, backends []Backend, requiredBackends []string, logger log.Logger) (Monitor, error) {
+	states := make(map[string]connectivity.State, len(backends))
+	for _, backend := range backends {
+		states[backend.ServiceName] = backend.Conn.GetState()
+	}
+
+	required := make(map[string]struct{}, len(requiredBackends))
+	for _, serviceName := range requiredBackends {
+		if _, ok := states[serviceName]; !ok {
+			return nil, errors.Errorf("unknown required backend %q", serviceName)
+		}
+		required[serviceName] = struct{}{}
+	}
+
+	m := &monitor{
+		healthServer:     healthServer,
+		backends:         backends,
+		requiredBackends: required,
+		logger:           logger,
+		states:           states,
+	}
+	m.updateStatus()
+
+	return m, nil
+}
+
+type monitor struct {
+	healthServer     *health.Server
+	backends         []Backend
+	requiredBackends map[string]struct{}
+	logger           log.Logger
+
+	mu       sync.RWMutex
+	states   map[string]connectivity.State
+	serving  bool
+	shutdown bool
+}
+
+func (m *monitor) Run(ctx context.Context) {
+	wg := sync.WaitGroup{}
+	for _, backend := range m.backends {
+		wg.Add(1)
+		go func(backend Backend) {
+			defer wg.Done()
+			m.watchBackend(ctx, backend)
+		}(backend)
+	}
+	wg.Wait()
+}
+
+func (m *monitor) Serving() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.serving && !m.shutdown
+}
+
+func (m *monitor) Shutdown() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.shutdown = true
+	m.healthServer.Shutdown()
+}
+
+func (m *monitor) watchBackend(ctx context.Context, backend Backend) {
+	state := backend.Conn.GetState()
+	for backend.Conn.WaitForStateChange(ctx, state) {
+		state = backend.Conn.GetState()
+
+		m.mu.Lock()
+		m.states[backend.ServiceName] = state
+		m.mu.Unlock()
+
+		m.logger.WithFields(log.Fields{
+			"service": backend.ServiceName,
+			"state":   state.String(),
+		}).Info("backend connection state changed")
+
+		m.updateStatus()
+	}
+}
+
+func (m *monitor) updateStatus() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.shutdown {
+		return
+	}
+
+	serving := true
+	for serviceName, state := range m.states {
+		backendServing := isServingState(state)
+		m.healthServer.SetServingStatus(serviceName, toServingStatus(backendServing))
+		if _, ok := m.requiredBackends[serviceName]; ok {
+			serving = serving && backendServing
+		}
+	}
+
+	m.serving = serving
+	m.healthServer.SetServingStatus("", toServingStatus(serving))
+	m.healthServer.SetServingStatus(APIGatewayServiceName, toServingStatus(serving))
+}
+
+// Idle connections are treated as serving since grpc dials lazily and connects on first call
+func isServingState(state connectivity.State) bool {
+	return state != connectivity.TransientFailure && state != connectivity.Shutdown
+}
+
+func toServingStatus(serving bool) healthpb.HealthCheckResponse_ServingStatus {
+	if serving {
+		return healthpb.HealthCheckResponse_SERVING
+	}
+	return healthpb.HealthCheckResponse_NOT_SERVING
+}
@@ -0,0 +1,91 @@
+package health
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	contentServiceName  = "contentservice.ContentService"
+	playlistServiceName = "playlistservice.PlayListService"
+)
+
+func TestMonitorStatus(t *testing.T) {
+	testCases := []struct {
+		name             string
+		requiredBackends map[string]struct{}
+		want             healthpb.HealthCheckResponse_ServingStatus
+	}{
+		{
+			name: "failed backend does not affect gateway",
+			want: healthpb.HealthCheckResponse_SERVING,
+		},
+		{
+			name:             "failed required backend",
+			requiredBackends: map[string]struct{}{playlistServiceName: {}},
+			want:             healthpb.HealthCheckResponse_NOT_SERVING,
+		},
+		{
+			name:             "serving required backend",
+			requiredBackends: map[string]struct{}{contentServiceName: {}},
+			want:             healthpb.HealthCheckResponse_SERVING,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			healthServer := health.NewServer()
+			m := &monitor{
+				healthServer:     healthServer,
+				requiredBackends: testCase.requiredBackends,
+				states: map[string]connectivity.State{
+					contentServiceName:  connectivity.Ready,
+					playlistServiceName: connectivity.TransientFailure,
+				},
+			}
+			m.updateStatus()
+
+			wantStatuses := map[string]healthpb.HealthCheckResponse_ServingStatus{
+				"":                    testCase.want,
+				APIGatewayServiceName: testCase.want,
+				contentServiceName:    healthpb.HealthCheckResponse_SERVING,
+				playlistServiceName:   healthpb.HealthCheckResponse_NOT_SERVING,
+			}
+			for serviceName, want := range wantStatuses {
+				resp, err := healthServer.Check(context.Background(), &healthpb.HealthCheckRequest{Service: serviceName})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if resp.Status != want {
+					t.Errorf("service %q: got %v, want %v", serviceName, resp.Status, want)
+				}
+			}
+			if m.Serving() != (testCase.want == healthpb.HealthCheckResponse_SERVING) {
+				t.Errorf("got serving %v, want %v", m.Serving(), testCase.want)
+			}
+		})
+	}
+}
+
+func TestMonitorShutdown(t *testing.T) {
+	healthServer := health.NewServer()
+	m := &monitor{healthServer: healthServer, states: map[string]connectivity.State{contentServiceName: connectivity.Ready}}
+	m.updateStatus()
+	m.Shutdown()
+	m.updateStatus()
+
+	if m.Serving() {
+		t.Error("got serving after shutdown")
+	}
+	resp, err := healthServer.Check(context.Background(), &healthpb.HealthCheckRequest{Service: APIGatewayServiceName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("got %v after shutdown, want NOT_SERVING", resp.Status)
+	}
+}
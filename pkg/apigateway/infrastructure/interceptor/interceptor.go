@@ -0,0 +1,62 @@
+package interceptor
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// Server holds unary and stream variants of the same server interceptor,
+// so cross-cutting behavior can not be installed for one kind of calls only
+type Server struct {
+	Unary  grpc.UnaryServerInterceptor
+	Stream grpc.StreamServerInterceptor
+}
+
+// Client holds unary and stream variants of the same client interceptor
+type Client struct {
+	Unary  grpc.UnaryClientInterceptor
+	Stream grpc.StreamClientInterceptor
+}
+
+// ChainServer returns server options chaining interceptors in given order for both unary and stream calls
+func ChainServer(interceptors ...Server) []grpc.ServerOption {
+	unary := make([]grpc.UnaryServerInterceptor, 0, len(interceptors))
+	stream := make([]grpc.StreamServerInterceptor, 0, len(interceptors))
+	for _, interceptor := range interceptors {
+		unary = append(unary, interceptor.Unary)
+		stream = append(stream, interceptor.Stream)
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	}
+}
+
+// ChainClient returns dial options chaining interceptors in given order for both unary and stream calls
+func ChainClient(interceptors ...Client) []grpc.DialOption {
+	unary := make([]grpc.UnaryClientInterceptor, 0, len(interceptors))
+	stream := make([]grpc.StreamClientInterceptor, 0, len(interceptors))
+	for _, interceptor := range interceptors {
+		unary = append(unary, interceptor.Unary)
+		stream = append(stream, interceptor.Stream)
+	}
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(unary...),
+		grpc.WithChainStreamInterceptor(stream...),
+	}
+}
+
+// WrapServerStream overrides context of stream, it is how stream interceptors pass values to handler
+func WrapServerStream(ctx context.Context, stream grpc.ServerStream) grpc.ServerStream {
+	return &wrappedServerStream{ServerStream: stream, ctx: ctx}
+}
+
+type wrappedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *wrappedServerStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,374 @@
+package listing
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// Filter matches records by AIP-160 style expression, e.g. type = Song AND (name:"live" OR NOT availabilityType = Private).
+// Supported comparators are =, !=, <, <=, >, >= and : which means substring for strings.
+// String = supports trailing * as prefix match. As in AIP-160 OR binds tighter than AND
+type Filter interface {
+	Match(record Record) bool
+}
+
+const (
+	MaxFilterLength = 1024
+	// maxFilterDepth limits nesting of parentheses and negations, so parser recursion is bounded
+	maxFilterDepth = 32
+)
+
+// ParseFilter returns filter matching any record for empty expression
+func ParseFilter(expr string, schema Schema) (Filter, error) {
+	if len(expr) > MaxFilterLength {
+		return nil, errors.Wrapf(ErrInvalidFilter, "filter is longer than %d bytes", MaxFilterLength)
+	}
+
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return matchAll{}, nil
+	}
+
+	p := &filterParser{tokens: tokens, schema: schema}
+	node, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, p.errorf("unexpected %q", p.peek().text)
+	}
+	return node, nil
+}
+
+type matchAll struct{}
+
+func (matchAll) Match(Record) bool {
+	return true
+}
+
+type andNode []Filter
+
+func (n andNode) Match(record Record) bool {
+	for _, f := range n {
+		if !f.Match(record) {
+			return false
+		}
+	}
+	return true
+}
+
+type orNode []Filter
+
+func (n orNode) Match(record Record) bool {
+	for _, f := range n {
+		if f.Match(record) {
+			return true
+		}
+	}
+	return false
+}
+
+type notNode struct {
+	Filter
+}
+
+func (n notNode) Match(record Record) bool {
+	return !n.Filter.Match(record)
+}
+
+type restriction struct {
+	field      string
+	kind       Kind
+	comparator string
+	// value is string for KindString, uint64 for KindNumber and enum index for KindEnum
+	value interface{}
+	enum  Field
+}
+
+func (r restriction) Match(record Record) bool {
+	switch r.kind {
+	case KindNumber:
+		actual, _ := record(r.field).(uint64)
+		return compareOrdered(actual, r.value.(uint64), r.comparator)
+	case KindEnum:
+		actualName, _ := record(r.field).(string)
+		actual, _ := r.enum.enumIndex(actualName)
+		return compareOrdered(uint64(actual), uint64(r.value.(int)), r.comparator)
+	default:
+		actual, _ := record(r.field).(string)
+		expected := r.value.(string)
+		switch r.comparator {
+		case ":":
+			return strings.Contains(strings.ToLower(actual), strings.ToLower(expected))
+		case "=", "!=":
+			equal := actual == expected
+			if strings.HasSuffix(expected, "*") {
+				equal = strings.HasPrefix(actual, strings.TrimSuffix(expected, "*"))
+			}
+			return equal == (r.comparator == "=")
+		default:
+			return compareOrdered(actual, expected, r.comparator)
+		}
+	}
+}
+
+func compareOrdered(actual, expected interface{}, comparator string) bool {
+	var less, equal bool
+	switch a := actual.(type) {
+	case uint64:
+		e := expected.(uint64)
+		less, equal = a < e, a == e
+	case string:
+		e := expected.(string)
+		less, equal = a < e, a == e
+	}
+
+	switch comparator {
+	case "=":
+		return equal
+	case "!=":
+		return !equal
+	case "<":
+		return less
+	case "<=":
+		return less || equal
+	case ">":
+		return !less && !equal
+	case ">=":
+		return !less
+	}
+	return false
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenString
+	tokenComparator
+	tokenLeftParen
+	tokenRightParen
+	tokenMinus
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLeftParen, text: "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRightParen, text: ")"})
+			i++
+		case r == '-':
+			tokens = append(tokens, token{kind: tokenMinus, text: "-"})
+			i++
+		case strings.ContainsRune("=!<>:", r):
+			comparator := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' && r != '=' && r != ':' {
+				comparator += "="
+			}
+			if comparator == "!" {
+				return nil, errors.Wrap(ErrInvalidFilter, "unexpected \"!\"")
+			}
+			tokens = append(tokens, token{kind: tokenComparator, text: comparator})
+			i += len(comparator)
+		case r == '"':
+			value, n, err := readQuoted(runes[i:])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, text: value})
+			i += n
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune("()=!<>:\"", runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: string(runes[start:i])})
+		}
+	}
+	return tokens, nil
+}
+
+// readQuoted reads double quoted string with backslash escapes and returns it with number of consumed runes
+func readQuoted(runes []rune) (string, int, error) {
+	var builder strings.Builder
+	for i := 1; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			if i+1 == len(runes) {
+				return "", 0, errors.Wrap(ErrInvalidFilter, "unterminated string")
+			}
+			i++
+			builder.WriteRune(runes[i])
+		case '"':
+			return builder.String(), i + 1, nil
+		default:
+			builder.WriteRune(runes[i])
+		}
+	}
+	return "", 0, errors.Wrap(ErrInvalidFilter, "unterminated string")
+}
+
+type filterParser struct {
+	tokens []token
+	pos    int
+	depth  int
+	schema Schema
+}
+
+// parseExpression parses sequences joined by AND
+func (p *filterParser) parseExpression() (Filter, error) {
+	var result andNode
+	for {
+		node, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, node)
+
+		if !p.done() && p.peek().kind == tokenWord && p.peek().text == "AND" {
+			p.pos++
+			continue
+		}
+		// Implicit AND of sequence, e.g. a = 1 b = 2
+		if !p.done() && p.peek().kind != tokenRightParen {
+			continue
+		}
+		break
+	}
+	if len(result) == 1 {
+		return result[0], nil
+	}
+	return result, nil
+}
+
+// parseFactor parses terms joined by OR
+func (p *filterParser) parseFactor() (Filter, error) {
+	var result orNode
+	for {
+		node, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, node)
+
+		if !p.done() && p.peek().kind == tokenWord && p.peek().text == "OR" {
+			p.pos++
+			continue
+		}
+		break
+	}
+	if len(result) == 1 {
+		return result[0], nil
+	}
+	return result, nil
+}
+
+func (p *filterParser) parseTerm() (Filter, error) {
+	if p.done() {
+		return nil, p.errorf("unexpected end of filter")
+	}
+
+	t := p.peek()
+	negation := t.kind == tokenMinus || (t.kind == tokenWord && t.text == "NOT")
+	if negation || t.kind == tokenLeftParen {
+		p.depth++
+		defer func() { p.depth-- }()
+		if p.depth > maxFilterDepth {
+			return nil, p.errorf("filter is nested deeper than %d levels", maxFilterDepth)
+		}
+	}
+
+	if negation {
+		p.pos++
+		node, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{node}, nil
+	}
+
+	if t.kind == tokenLeftParen {
+		p.pos++
+		node, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		if p.done() || p.peek().kind != tokenRightParen {
+			return nil, p.errorf("missing \")\"")
+		}
+		p.pos++
+		return node, nil
+	}
+
+	return p.parseRestriction()
+}
+
+func (p *filterParser) parseRestriction() (Filter, error) {
+	if p.pos+3 > len(p.tokens) {
+		return nil, p.errorf("expected <field> <comparator> <value> at %q", p.peek().text)
+	}
+	fieldToken, comparatorToken, valueToken := p.tokens[p.pos], p.tokens[p.pos+1], p.tokens[p.pos+2]
+	if fieldToken.kind != tokenWord || comparatorToken.kind != tokenComparator ||
+		(valueToken.kind != tokenWord && valueToken.kind != tokenString) {
+		return nil, p.errorf("expected <field> <comparator> <value> at %q", fieldToken.text)
+	}
+	p.pos += 3
+
+	field, ok := p.schema[fieldToken.text]
+	if !ok {
+		return nil, p.errorf("unknown field %q", fieldToken.text)
+	}
+
+	r := restriction{field: fieldToken.text, kind: field.Kind, comparator: comparatorToken.text, enum: field}
+	switch field.Kind {
+	case KindNumber:
+		value, err := strconv.ParseUint(valueToken.text, 10, 64)
+		if err != nil || comparatorToken.text == ":" {
+			return nil, p.errorf("field %q requires number comparison", fieldToken.text)
+		}
+		r.value = value
+	case KindEnum:
+		index, ok := field.enumIndex(valueToken.text)
+		if !ok {
+			return nil, p.errorf("unknown value %q of field %q", valueToken.text, fieldToken.text)
+		}
+		if comparatorToken.text != "=" && comparatorToken.text != "!=" {
+			return nil, p.errorf("field %q supports only = and !=", fieldToken.text)
+		}
+		r.value = index
+	default:
+		r.value = valueToken.text
+	}
+	return r, nil
+}
+
+func (p *filterParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *filterParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) errorf(format string, args ...interface{}) error {
+	return errors.Wrapf(ErrInvalidFilter, format, args...)
+}
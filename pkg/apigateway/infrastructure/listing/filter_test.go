@@ -0,0 +1,110 @@
+package listing
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+var testSchema = Schema{
+	"name":      {Kind: KindString},
+	"createdAt": {Kind: KindNumber},
+	"type":      {Kind: KindEnum, Values: []string{"Song", "Podcast"}},
+}
+
+func testRecord(name string, createdAt uint64, contentType string) Record {
+	return func(field string) interface{} {
+		switch field {
+		case "name":
+			return name
+		case "createdAt":
+			return createdAt
+		case "type":
+			return contentType
+		}
+		return nil
+	}
+}
+
+func TestParseFilterMatch(t *testing.T) {
+	record := testRecord("Live at Wembley", 100, "Song")
+
+	tests := []struct {
+		filter string
+		match  bool
+	}{
+		{filter: "", match: true},
+		{filter: "name = \"Live at Wembley\"", match: true},
+		{filter: "name = Live*", match: true},
+		{filter: "name != Live*", match: false},
+		{filter: "name:wembley", match: true},
+		{filter: "createdAt >= 100 AND createdAt < 101", match: true},
+		{filter: "createdAt > 100", match: false},
+		{filter: "type = Song", match: true},
+		{filter: "type != Song", match: false},
+		{filter: "NOT type = Podcast", match: true},
+		{filter: "-type = Song", match: false},
+		{filter: "type = Podcast OR createdAt = 100", match: true},
+		// OR binds tighter than AND
+		{filter: "createdAt = 1 OR createdAt = 100 AND type = Podcast", match: false},
+		{filter: "(type = Podcast OR name:live) createdAt = 100", match: true},
+	}
+	for _, test := range tests {
+		filter, err := ParseFilter(test.filter, testSchema)
+		if err != nil {
+			t.Fatalf("ParseFilter(%q): %v", test.filter, err)
+		}
+		if match := filter.Match(record); match != test.match {
+			t.Errorf("ParseFilter(%q).Match() = %v, want %v", test.filter, match, test.match)
+		}
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	tests := []string{
+		"unknown = 1",
+		"type = Video",
+		"type > Song",
+		"createdAt = abc",
+		"createdAt : 1",
+		"(name = a",
+		"name = a)",
+		"name =",
+		"name ! a",
+		"name = \"unterminated",
+		"AND",
+		strings.Repeat("(", maxFilterDepth+1) + "name = a" + strings.Repeat(")", maxFilterDepth+1),
+		strings.Repeat("NOT ", maxFilterDepth+1) + "name = a",
+		"name = " + strings.Repeat("a", MaxFilterLength),
+	}
+	for _, test := range tests {
+		_, err := ParseFilter(test, testSchema)
+		if errors.Cause(err) != ErrInvalidFilter {
+			t.Errorf("ParseFilter(%.40q) error = %v, want ErrInvalidFilter", test, err)
+		}
+	}
+}
+
+func TestParseFilterMaxDepth(t *testing.T) {
+	filter := strings.Repeat("(", maxFilterDepth) + "name = a" + strings.Repeat(")", maxFilterDepth)
+	if _, err := ParseFilter(filter, testSchema); err != nil {
+		t.Fatalf("ParseFilter at max depth: %v", err)
+	}
+}
+
+func FuzzParseFilter(f *testing.F) {
+	f.Add("name = a AND (type = Song OR NOT createdAt > 10)")
+	f.Add("-name:\"x\\\"y\" createdAt <= 5")
+	f.Add(strings.Repeat("(", 100))
+	f.Fuzz(func(t *testing.T, expr string) {
+		filter, err := ParseFilter(expr, testSchema)
+		if err != nil {
+			if errors.Cause(err) != ErrInvalidFilter {
+				t.Fatalf("ParseFilter(%q) error = %v, want ErrInvalidFilter", expr, err)
+			}
+			return
+		}
+		filter.Match(testRecord("a", 1, "Song"))
+	})
+}
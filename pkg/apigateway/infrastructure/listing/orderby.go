@@ -0,0 +1,108 @@
+package listing
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// OrderBy sorts records by comma separated fields with optional direction, e.g. "type, createdAtTimestamp desc"
+type OrderBy interface {
+	// Key returns key of record, keys are compared as strings in the same order as records
+	Key(record Record) string
+}
+
+const (
+	MaxOrderByLength = 256
+)
+
+type orderByField struct {
+	name       string
+	field      Field
+	descending bool
+}
+
+type orderBy []orderByField
+
+// ParseOrderBy returns order by with empty keys for empty expression
+func ParseOrderBy(expr string, schema Schema) (OrderBy, error) {
+	if len(expr) > MaxOrderByLength {
+		return nil, errors.Wrapf(ErrInvalidOrderBy, "order by is longer than %d bytes", MaxOrderByLength)
+	}
+
+	var result orderBy
+	if strings.TrimSpace(expr) == "" {
+		return result, nil
+	}
+
+	for _, part := range strings.Split(expr, ",") {
+		words := strings.Fields(part)
+		if len(words) == 0 || len(words) > 2 {
+			return nil, errors.Wrapf(ErrInvalidOrderBy, "expected <field> [asc|desc] at %q", strings.TrimSpace(part))
+		}
+
+		field, ok := schema[words[0]]
+		if !ok {
+			return nil, errors.Wrapf(ErrInvalidOrderBy, "unknown field %q", words[0])
+		}
+
+		descending := false
+		if len(words) == 2 {
+			switch strings.ToLower(words[1]) {
+			case "asc":
+			case "desc":
+				descending = true
+			default:
+				return nil, errors.Wrapf(ErrInvalidOrderBy, "unknown direction %q", words[1])
+			}
+		}
+		result = append(result, orderByField{name: words[0], field: field, descending: descending})
+	}
+	return result, nil
+}
+
+// Key encodes values so byte order of encoded tuple matches order of values,
+// hex is used so keys stay valid strings for page tokens
+func (o orderBy) Key(record Record) string {
+	var key []byte
+	for _, f := range o {
+		var encoded []byte
+		switch f.field.Kind {
+		case KindNumber:
+			value, _ := record(f.name).(uint64)
+			encoded = make([]byte, 8)
+			binary.BigEndian.PutUint64(encoded, value)
+		case KindEnum:
+			name, _ := record(f.name).(string)
+			index, _ := f.field.enumIndex(name)
+			encoded = make([]byte, 8)
+			binary.BigEndian.PutUint64(encoded, uint64(index))
+		default:
+			value, _ := record(f.name).(string)
+			encoded = encodeString(value)
+		}
+
+		if f.descending {
+			for i := range encoded {
+				encoded[i] = ^encoded[i]
+			}
+		}
+		key = append(key, encoded...)
+	}
+	return hex.EncodeToString(key)
+}
+
+// encodeString escapes zero bytes and terminates string, so shorter string is ordered before longer one with the same prefix
+func encodeString(value string) []byte {
+	encoded := make([]byte, 0, len(value)+2)
+	for i := 0; i < len(value); i++ {
+		if value[i] == 0 {
+			encoded = append(encoded, 0, 0xFF)
+			continue
+		}
+		encoded = append(encoded, value[i])
+	}
+	return append(encoded, 0, 1)
+}
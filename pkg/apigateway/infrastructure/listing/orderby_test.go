@@ -0,0 +1,95 @@
+package listing
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestOrderByKey(t *testing.T) {
+	records := []struct {
+		id     string
+		record Record
+	}{
+		{id: "a", record: testRecord("b", 2, "Podcast")},
+		{id: "b", record: testRecord("a", 3, "Song")},
+		{id: "c", record: testRecord("a\x00", 1, "Song")},
+		{id: "d", record: testRecord("ab", 1, "Podcast")},
+		{id: "e", record: testRecord("", 256, "Song")},
+	}
+
+	tests := []struct {
+		orderBy string
+		want    []string
+	}{
+		{orderBy: "name", want: []string{"e", "b", "c", "d", "a"}},
+		{orderBy: "name desc", want: []string{"a", "d", "c", "b", "e"}},
+		{orderBy: "createdAt", want: []string{"c", "d", "a", "b", "e"}},
+		{orderBy: "createdAt desc", want: []string{"e", "b", "a", "c", "d"}},
+		{orderBy: "type, name desc", want: []string{"c", "b", "e", "a", "d"}},
+		{orderBy: "type desc, createdAt", want: []string{"d", "a", "c", "b", "e"}},
+	}
+	for _, test := range tests {
+		orderBy, err := ParseOrderBy(test.orderBy, testSchema)
+		if err != nil {
+			t.Fatalf("ParseOrderBy(%q): %v", test.orderBy, err)
+		}
+
+		ids := make([]string, len(records))
+		keys := make(map[string]string, len(records))
+		for i, r := range records {
+			ids[i] = r.id
+			keys[r.id] = orderBy.Key(r.record)
+		}
+		sort.SliceStable(ids, func(i, j int) bool {
+			return keys[ids[i]] < keys[ids[j]]
+		})
+
+		for i := range ids {
+			if ids[i] != test.want[i] {
+				t.Errorf("order by %q = %v, want %v", test.orderBy, ids, test.want)
+				break
+			}
+		}
+	}
+}
+
+func TestEncodeStringOrder(t *testing.T) {
+	// Sorted by byte order, including strings with zero bytes and prefixes
+	values := []string{"", "\x00", "\x00\x00", "\x00\x01", "\x01", "a", "a\x00", "a\x00b", "a\x01", "ab", "b"}
+	for i := 1; i < len(values); i++ {
+		prev, next := string(encodeString(values[i-1])), string(encodeString(values[i]))
+		if prev >= next {
+			t.Errorf("encodeString(%q) >= encodeString(%q)", values[i-1], values[i])
+		}
+
+		prevDesc, nextDesc := complement(encodeString(values[i-1])), complement(encodeString(values[i]))
+		if prevDesc <= nextDesc {
+			t.Errorf("descending encodeString(%q) <= encodeString(%q)", values[i-1], values[i])
+		}
+	}
+}
+
+func TestParseOrderByErrors(t *testing.T) {
+	tests := []string{
+		"unknown",
+		"name up",
+		"name asc desc",
+		"name,",
+		string(make([]byte, MaxOrderByLength+1)),
+	}
+	for _, test := range tests {
+		_, err := ParseOrderBy(test, testSchema)
+		if errors.Cause(err) != ErrInvalidOrderBy {
+			t.Errorf("ParseOrderBy(%.40q) error = %v, want ErrInvalidOrderBy", test, err)
+		}
+	}
+}
+
+func complement(encoded []byte) string {
+	for i := range encoded {
+		encoded[i] = ^encoded[i]
+	}
+	return string(encoded)
+}
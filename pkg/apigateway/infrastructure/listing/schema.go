@@ -0,0 +1,40 @@
+package listing
+
+import (
+	"github.com/pkg/errors"
+)
+
+type Kind int
+
+const (
+	KindString Kind = iota
+	KindNumber
+	KindEnum
+)
+
+var (
+	ErrInvalidFilter  = errors.New("invalid filter")
+	ErrInvalidOrderBy = errors.New("invalid order by")
+)
+
+// Field describes field which can be used in filter and order by
+type Field struct {
+	Kind Kind
+	// Values are enum value names in enum number order, they are ordered the same way
+	Values []string
+}
+
+// Schema maps field names to their descriptions
+type Schema map[string]Field
+
+// Record returns value of field, string for KindString and KindEnum and uint64 for KindNumber
+type Record func(field string) interface{}
+
+func (f Field) enumIndex(value string) (int, bool) {
+	for i, v := range f.Values {
+		if v == value {
+			return i, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,101 @@
+package logging
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const MaxDebugSessionDuration = 24 * time.Hour
+
+var ErrInvalidDebugSession = errors.New("invalid debug session")
+
+// DebugSession enables debug logging of requests made by user or within activity until ExpiresAt
+type DebugSession struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"userID,omitempty"`
+	ActivityID string    `json:"activityID,omitempty"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
+type DebugSessions interface {
+	// Start assigns ID to session, session must have UserID or ActivityID and expire within MaxDebugSessionDuration
+	Start(session DebugSession) (DebugSession, error)
+	Stop(id string) bool
+	List() []DebugSession
+	Enabled(userID, activityID string) bool
+}
+
+func NewDebugSessions() DebugSessions {
+	return &debugSessions{sessions: map[string]DebugSession{}}
+}
+
+type debugSessions struct {
+	mutex    sync.Mutex
+	sessions map[string]DebugSession
+}
+
+func (s *debugSessions) Start(session DebugSession) (DebugSession, error) {
+	if session.UserID == "" && session.ActivityID == "" {
+		return DebugSession{}, errors.Wrap(ErrInvalidDebugSession, "userID or activityID is required")
+	}
+	now := time.Now()
+	if !session.ExpiresAt.After(now) || session.ExpiresAt.Sub(now) > MaxDebugSessionDuration {
+		return DebugSession{}, errors.Wrapf(ErrInvalidDebugSession, "session must expire within %v", MaxDebugSessionDuration)
+	}
+
+	session.ID = uuid.New().String()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.sessions[session.ID] = session
+	return session, nil
+}
+
+func (s *debugSessions) Stop(id string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, ok := s.sessions[id]
+	delete(s.sessions, id)
+	return ok
+}
+
+func (s *debugSessions) List() []DebugSession {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.removeExpired()
+	result := make([]DebugSession, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		result = append(result, session)
+	}
+	return result
+}
+
+func (s *debugSessions) Enabled(userID, activityID string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.removeExpired()
+	for _, session := range s.sessions {
+		if session.UserID != "" && session.UserID == userID {
+			return true
+		}
+		if session.ActivityID != "" && session.ActivityID == activityID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *debugSessions) removeExpired() {
+	now := time.Now()
+	for id, session := range s.sessions {
+		if !session.ExpiresAt.After(now) {
+			delete(s.sessions, id)
+		}
+	}
+}
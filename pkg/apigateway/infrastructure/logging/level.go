@@ -0,0 +1,113 @@
+package logging
+
+import (
+	"sync/atomic"
+
+	log "github.com/CuriosityMusicStreaming/ComponentsPool/pkg/app/logger"
+	"github.com/pkg/errors"
+)
+
+type Level string
+
+const (
+	// LevelDebug enables debug entries, e.g. backend calls, for all requests
+	LevelDebug = Level("debug")
+	LevelInfo  = Level("info")
+	LevelError = Level("error")
+)
+
+var ErrUnknownLevel = errors.New("unknown log level")
+
+var levelPriorities = map[Level]int32{
+	LevelDebug: 0,
+	LevelInfo:  1,
+	LevelError: 2,
+}
+
+// LevelLogger drops entries below level which can be changed at runtime,
+// loggers derived by WithField share level with root logger
+type LevelLogger interface {
+	log.MainLogger
+	Level() Level
+	SetLevel(level Level) error
+	// Unfiltered returns logger writing entries regardless of level, it is used by debug sessions
+	Unfiltered() log.Logger
+}
+
+func NewLevelLogger(logger log.MainLogger, level Level) (LevelLogger, error) {
+	priority, ok := levelPriorities[level]
+	if !ok {
+		return nil, errors.Wrapf(ErrUnknownLevel, "%q", level)
+	}
+
+	state := &levelState{priority: priority}
+	return &mainLevelLogger{
+		levelLogger: levelLogger{logger: logger, state: state},
+		mainLogger:  logger,
+	}, nil
+}
+
+type levelState struct {
+	priority int32
+}
+
+func (s *levelState) enabled(level Level) bool {
+	return levelPriorities[level] >= atomic.LoadInt32(&s.priority)
+}
+
+type levelLogger struct {
+	logger log.Logger
+	state  *levelState
+}
+
+func (l *levelLogger) WithField(key string, value interface{}) log.Logger {
+	return &levelLogger{logger: l.logger.WithField(key, value), state: l.state}
+}
+
+func (l *levelLogger) WithFields(fields log.Fields) log.Logger {
+	return &levelLogger{logger: l.logger.WithFields(fields), state: l.state}
+}
+
+func (l *levelLogger) Info(args ...interface{}) {
+	if l.state.enabled(LevelInfo) {
+		l.logger.Info(args...)
+	}
+}
+
+func (l *levelLogger) Error(err error, args ...interface{}) {
+	if l.state.enabled(LevelError) {
+		l.logger.Error(err, args...)
+	}
+}
+
+type mainLevelLogger struct {
+	levelLogger
+	mainLogger log.MainLogger
+}
+
+func (l *mainLevelLogger) FatalError(err error, args ...interface{}) {
+	l.mainLogger.FatalError(err, args...)
+}
+
+func (l *mainLevelLogger) Unfiltered() log.Logger {
+	return l.mainLogger
+}
+
+func (l *mainLevelLogger) Level() Level {
+	priority := atomic.LoadInt32(&l.state.priority)
+	for level, levelPriority := range levelPriorities {
+		if levelPriority == priority {
+			return level
+		}
+	}
+	return LevelInfo
+}
+
+func (l *mainLevelLogger) SetLevel(level Level) error {
+	priority, ok := levelPriorities[level]
+	if !ok {
+		return errors.Wrapf(ErrUnknownLevel, "%q", level)
+	}
+	atomic.StoreInt32(&l.state.priority, priority)
+	return nil
+}
@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/connectivity"
+
+	"apigateway/pkg/apigateway/infrastructure/health"
+)
+
+var connectivityStates = []connectivity.State{
+	connectivity.Idle,
+	connectivity.Connecting,
+	connectivity.Ready,
+	connectivity.TransientFailure,
+	connectivity.Shutdown,
+}
+
+// NewConnectionStateCollector returns collector reporting current connectivity state of each backend connection,
+// gauge of current state is 1 and gauges of other states are 0
+func NewConnectionStateCollector(backends []health.Backend) prometheus.Collector {
+	return &connectionStateCollector{
+		backends: backends,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "grpc_client", "connection_state"),
+			"Connectivity state of backend service connection",
+			[]string{"service", "state"},
+			nil,
+		),
+	}
+}
+
+type connectionStateCollector struct {
+	backends []health.Backend
+	desc     *prometheus.Desc
+}
+
+func (c *connectionStateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *connectionStateCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, backend := range c.backends {
+		current := backend.Conn.GetState()
+		for _, state := range connectivityStates {
+			value := 0.0
+			if state == current {
+				value = 1
+			}
+			ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, value, backend.ServiceName, state.String())
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"net/http"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// GatewayRoutes resolves HTTP rule pattern of REST gateway request, router matches gateway requests only by path prefix
+type GatewayRoutes interface {
+	// Match returns pattern like /api/v1/playlists/{playlistID}
+	Match(r *http.Request) (string, bool)
+}
+
+// NewGatewayRoutes collects HTTP rules of service methods including additional bindings,
+// variables with multi-segment templates like {name=shelves/*} are not supported
+func NewGatewayRoutes(service protoreflect.ServiceDescriptor) GatewayRoutes {
+	var routes gatewayRoutes
+	methods := service.Methods()
+	for i := 0; i < methods.Len(); i++ {
+		rule, ok := proto.GetExtension(methods.Get(i).Options(), annotations.E_Http).(*annotations.HttpRule)
+		if !ok || rule == nil {
+			continue
+		}
+		routes = append(routes, newGatewayRoute(rule))
+		for _, binding := range rule.AdditionalBindings {
+			routes = append(routes, newGatewayRoute(binding))
+		}
+	}
+	return routes
+}
+
+type gatewayRoute struct {
+	method   string
+	pattern  string
+	segments []string
+}
+
+type gatewayRoutes []gatewayRoute
+
+// Match prefers route with more literal segments, e.g. /playlists/merge over /playlists/{playlistID}
+func (routes gatewayRoutes) Match(r *http.Request) (string, bool) {
+	segments := splitPath(r.URL.Path)
+	best, bestLiterals := "", -1
+	for _, route := range routes {
+		if route.method != r.Method {
+			continue
+		}
+		literals, ok := route.match(segments)
+		if ok && literals > bestLiterals {
+			best, bestLiterals = route.pattern, literals
+		}
+	}
+	return best, bestLiterals >= 0
+}
+
+func newGatewayRoute(rule *annotations.HttpRule) gatewayRoute {
+	var method, pattern string
+	switch p := rule.Pattern.(type) {
+	case *annotations.HttpRule_Get:
+		method, pattern = http.MethodGet, p.Get
+	case *annotations.HttpRule_Post:
+		method, pattern = http.MethodPost, p.Post
+	case *annotations.HttpRule_Put:
+		method, pattern = http.MethodPut, p.Put
+	case *annotations.HttpRule_Patch:
+		method, pattern = http.MethodPatch, p.Patch
+	case *annotations.HttpRule_Delete:
+		method, pattern = http.MethodDelete, p.Delete
+	case *annotations.HttpRule_Custom:
+		method, pattern = p.Custom.Kind, p.Custom.Path
+	}
+	return gatewayRoute{method: method, pattern: pattern, segments: splitPath(pattern)}
+}
+
+// match returns number of matched literal segments, variables match single segment unless they are bound to **
+func (route gatewayRoute) match(segments []string) (int, bool) {
+	literals := 0
+	for i, templateSegment := range route.segments {
+		if templateSegment == "**" || strings.HasSuffix(templateSegment, "=**}") {
+			return literals, true
+		}
+		if i == len(segments) {
+			return 0, false
+		}
+		if templateSegment == "*" || strings.HasPrefix(templateSegment, "{") {
+			continue
+		}
+		if templateSegment != segments[i] {
+			return 0, false
+		}
+		literals++
+	}
+	return literals, len(route.segments) == len(segments)
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
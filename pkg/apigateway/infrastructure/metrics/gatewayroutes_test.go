@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"apigateway/api/apigateway"
+)
+
+func TestGatewayRoutesMatch(t *testing.T) {
+	routes := NewGatewayRoutes(apigateway.File_apigateway_proto.Services().ByName("APIGateway"))
+
+	testCases := []struct {
+		method  string
+		path    string
+		pattern string
+	}{
+		{method: "GET", path: "/api/v1/playlists", pattern: "/api/v1/playlists"},
+		{method: "POST", path: "/api/v1/playlists", pattern: "/api/v1/playlists"},
+		{method: "GET", path: "/api/v1/playlists/p1", pattern: "/api/v1/playlists/{playlistID}"},
+		{method: "GET", path: "/api/v1/playlists/p1/detailed", pattern: "/api/v1/playlists/{playlistID}/detailed"},
+		{method: "POST", path: "/api/v1/playlists/merge", pattern: "/api/v1/playlists/merge"},
+		{method: "DELETE", path: "/api/v1/playlists/items/i1", pattern: "/api/v1/playlists/items/{playlistItemID}"},
+		{method: "GET", path: "/api/v1/content/author", pattern: "/api/v1/content/author"},
+		{method: "PUT", path: "/api/v1/playlists/p1/name/", pattern: "/api/v1/playlists/{playlistID}/name"},
+		{method: "PUT", path: "/api/v1/playlists/p1"},
+		{method: "GET", path: "/api/v1/playlists/p1/unknown"},
+		{method: "GET", path: "/api/v1"},
+	}
+	for _, testCase := range testCases {
+		pattern, ok := routes.Match(httptest.NewRequest(testCase.method, testCase.path, nil))
+		if pattern != testCase.pattern || ok != (testCase.pattern != "") {
+			t.Errorf("%s %s: got %q, %v, want %q", testCase.method, testCase.path, pattern, ok, testCase.pattern)
+		}
+	}
+}
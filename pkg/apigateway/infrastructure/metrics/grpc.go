@@ -0,0 +1,145 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"apigateway/pkg/apigateway/infrastructure/interceptor"
+)
+
+// NewServerInterceptor returns interceptor that counts handled requests and observes their latency per method and status code
+func NewServerInterceptor(registerer prometheus.Registerer) (interceptor.Server, error) {
+	m := newRequestMetrics("grpc_server", "gRPC requests handled by gateway")
+	if err := m.register(registerer); err != nil {
+		return interceptor.Server{}, err
+	}
+
+	return interceptor.Server{
+		Unary: func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			service, method := splitFullMethod(info.FullMethod)
+			done := m.start(service, method)
+
+			resp, err := handler(ctx, req)
+
+			done(status.Code(err).String())
+			return resp, err
+		},
+		Stream: func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			service, method := splitFullMethod(info.FullMethod)
+			done := m.start(service, method)
+
+			err := handler(srv, stream)
+
+			done(status.Code(err).String())
+			return err
+		},
+	}, nil
+}
+
+// NewClientInterceptor returns interceptor that counts backend calls and observes their latency per backend method and status code,
+// stream call is finished when stream is closed by backend or fails
+func NewClientInterceptor(registerer prometheus.Registerer) (interceptor.Client, error) {
+	m := newRequestMetrics("grpc_client", "gRPC calls made by gateway to backend services")
+	if err := m.register(registerer); err != nil {
+		return interceptor.Client{}, err
+	}
+
+	return interceptor.Client{
+		Unary: func(ctx context.Context, fullMethod string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			service, method := splitFullMethod(fullMethod)
+			done := m.start(service, method)
+
+			err := invoker(ctx, fullMethod, req, reply, cc, opts...)
+
+			done(status.Code(err).String())
+			return err
+		},
+		Stream: func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, fullMethod string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			service, method := splitFullMethod(fullMethod)
+			done := m.start(service, method)
+
+			stream, err := streamer(ctx, desc, cc, fullMethod, opts...)
+			if err != nil {
+				done(status.Code(err).String())
+				return nil, err
+			}
+			return &clientStream{ClientStream: stream, done: done}, nil
+		},
+	}, nil
+}
+
+// clientStream records result of call once RecvMsg returns error, io.EOF means stream is successfully finished
+type clientStream struct {
+	grpc.ClientStream
+	done     func(code string)
+	doneOnce sync.Once
+}
+
+func (s *clientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		code := status.Code(err)
+		if errors.Is(err, io.EOF) {
+			code = codes.OK
+		}
+		s.doneOnce.Do(func() {
+			s.done(code.String())
+		})
+	}
+	return err
+}
+
+type requestMetrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight *prometheus.GaugeVec
+}
+
+func newRequestMetrics(subsystem, help string) *requestMetrics {
+	return &requestMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "requests_total",
+			Help:      "Total number of " + help,
+		}, []string{"service", "method", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "request_duration_seconds",
+			Help:      "Latency of " + help,
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"service", "method", "code"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "requests_in_flight",
+			Help:      "Number of in-flight " + help,
+		}, []string{"service", "method"}),
+	}
+}
+
+func (m *requestMetrics) register(registerer prometheus.Registerer) error {
+	return registerAll(registerer, m.requests, m.duration, m.inFlight)
+}
+
+// start marks request as in-flight and returns func that records its result with given code
+func (m *requestMetrics) start(service, method string) func(code string) {
+	start := time.Now()
+	inFlight := m.inFlight.WithLabelValues(service, method)
+	inFlight.Inc()
+
+	return func(code string) {
+		inFlight.Dec()
+		m.requests.WithLabelValues(service, method, code).Inc()
+		m.duration.WithLabelValues(service, method, code).Observe(time.Since(start).Seconds())
+	}
+}
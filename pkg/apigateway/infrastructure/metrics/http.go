@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// NewHTTPMiddleware returns router middleware that counts requests and observes their latency per route, method and status code.
+// Route of REST gateway request is its HTTP rule pattern, gatewayRoutes may be nil
+func NewHTTPMiddleware(registerer prometheus.Registerer, gatewayRoutes GatewayRoutes) (mux.MiddlewareFunc, error) {
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Total number of HTTP requests handled by gateway",
+	}, []string{"route", "method", "code"})
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of HTTP requests handled by gateway",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "code"})
+	inFlight := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_in_flight",
+		Help:      "Number of in-flight HTTP requests handled by gateway",
+	}, []string{"route", "method"})
+
+	if err := registerAll(registerer, requests, duration, inFlight); err != nil {
+		return nil, err
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := routeTemplate(r, gatewayRoutes)
+			start := time.Now()
+
+			routeInFlight := inFlight.WithLabelValues(route, r.Method)
+			routeInFlight.Inc()
+			defer routeInFlight.Dec()
+
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+
+			code := strconv.Itoa(recorder.status)
+			requests.WithLabelValues(route, r.Method, code).Inc()
+			duration.WithLabelValues(route, r.Method, code).Observe(time.Since(start).Seconds())
+		})
+	}, nil
+}
+
+func routeTemplate(r *http.Request, gatewayRoutes GatewayRoutes) string {
+	if gatewayRoutes != nil {
+		if pattern, ok := gatewayRoutes.Match(r); ok {
+			return pattern
+		}
+	}
+
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return "unknown"
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return "unknown"
+	}
+	return template
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "apigateway"
+
+// NewRegistry returns registry with go runtime and process collectors
+func NewRegistry() (*prometheus.Registry, error) {
+	registry := prometheus.NewRegistry()
+	err := registerAll(
+		registry,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return registry, err
+}
+
+func NewHandler(registry *prometheus.Registry) http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+func registerAll(registerer prometheus.Registerer, cs ...prometheus.Collector) error {
+	for _, c := range cs {
+		if err := registerer.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitFullMethod splits grpc method like /package.Service/Method into service and method
+func splitFullMethod(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.Index(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "unknown", fullMethod
+}
@@ -0,0 +1,41 @@
+package tracing
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"apigateway/pkg/apigateway/infrastructure/interceptor"
+)
+
+// NewServerInterceptor starts span for incoming call continuing trace from traceparent metadata
+func NewServerInterceptor() interceptor.Server {
+	return interceptor.Server{
+		Unary:  otelgrpc.UnaryServerInterceptor(),
+		Stream: otelgrpc.StreamServerInterceptor(),
+	}
+}
+
+// NewClientInterceptor starts span for outgoing call and passes traceparent and baggage in outgoing metadata
+func NewClientInterceptor() interceptor.Client {
+	return interceptor.Client{
+		Unary:  otelgrpc.UnaryClientInterceptor(),
+		Stream: otelgrpc.StreamClientInterceptor(),
+	}
+}
+
+// NewHTTPMiddleware starts span named by matched route for incoming HTTP request
+func NewHTTPMiddleware() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return otelhttp.NewHandler(next, "", otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			if route := mux.CurrentRoute(r); route != nil {
+				if template, err := route.GetPathTemplate(); err == nil {
+					return r.Method + " " + template
+				}
+			}
+			return r.Method
+		}))
+	}
+}
@@ -0,0 +1,103 @@
+package tracing
+
+import (
+	"context"
+	"os"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+const (
+	// ExporterNone keeps only trace context propagation without recording spans
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+)
+
+var ErrUnknownExporter = errors.New("unknown tracing exporter")
+
+type Config struct {
+	ServiceName  string
+	Exporter     string
+	OTLPEndpoint string
+	OTLPInsecure bool
+	FilePath     string
+	SampleRatio  float64
+}
+
+type ShutdownFunc func(ctx context.Context) error
+
+// InitTracerProvider sets global tracer provider and W3C trace context and baggage propagators,
+// returned func flushes recorded spans and must be called on service stop
+func InitTracerProvider(ctx context.Context, config Config) (ShutdownFunc, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if config.Exporter == ExporterNone {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, closeExporter, err := newExporter(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(config.ServiceName)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closeErr := closeExporter(); err == nil {
+			err = closeErr
+		}
+		return err
+	}, nil
+}
+
+func newExporter(ctx context.Context, config Config) (sdktrace.SpanExporter, func() error, error) {
+	noopClose := func() error { return nil }
+
+	switch config.Exporter {
+	case ExporterOTLP:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(config.OTLPEndpoint)}
+		if config.OTLPInsecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		exporter, err := otlptracegrpc.New(ctx, opts...)
+		return exporter, noopClose, errors.Wrap(err, "failed to create otlp exporter")
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		return exporter, noopClose, errors.Wrap(err, "failed to create stdout exporter")
+	case ExporterFile:
+		file, err := os.OpenFile(config.FilePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to open traces file")
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		return exporter, file.Close, errors.Wrap(err, "failed to create file exporter")
+	default:
+		return nil, nil, errors.Wrapf(ErrUnknownExporter, "exporter %q", config.Exporter)
+	}
+}
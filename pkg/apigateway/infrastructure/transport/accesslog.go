@@ -0,0 +1,103 @@
+package transport
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+
+	log "github.com/CuriosityMusicStreaming/ComponentsPool/pkg/app/logger"
+	"github.com/CuriosityMusicStreaming/ComponentsPool/pkg/infrastructure/activity"
+	"github.com/gorilla/mux"
+)
+
+type AccessLogConfig struct {
+	// SampleRatio is share of successful requests to log, failed requests are always logged
+	SampleRatio float64
+	// ExcludedPaths are not logged, e.g. health checks
+	ExcludedPaths []string
+	// TrustedProxies are load balancers whose X-Forwarded-For is used as client IP
+	TrustedProxies TrustedProxies
+}
+
+// NewAccessLogMiddleware returns router middleware that logs REST requests.
+// Request without valid X-Request-ID gets new one, so grpc call made by REST gateway has the same activity ID
+func NewAccessLogMiddleware(logger log.Logger, config AccessLogConfig) mux.MiddlewareFunc {
+	excludedPaths := make(map[string]struct{}, len(config.ExcludedPaths))
+	for _, path := range config.ExcludedPaths {
+		excludedPaths[path] = struct{}{}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			activityID, err := activity.ParseActivityID(r.Header.Get(RequestIDHeader))
+			if err != nil {
+				activityID = activity.NewActivityID()
+				r.Header.Set(RequestIDHeader, activityID.String())
+			}
+
+			if _, ok := excludedPaths[r.URL.Path]; ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			recorder := &accessLogRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+
+			failed := recorder.status >= http.StatusInternalServerError
+			if !failed && rand.Float64() >= config.SampleRatio {
+				return
+			}
+
+			entry := logger.WithFields(log.Fields{
+				"activityID": activityID.String(),
+				"method":     r.Method,
+				"path":       r.URL.Path,
+				"status":     recorder.status,
+				"bytes":      recorder.bytes,
+				"duration":   fmt.Sprintf("%v", time.Since(start)),
+				"userAgent":  r.UserAgent(),
+				"clientIP":   clientIP(r, config.TrustedProxies),
+			})
+			if failed {
+				entry.Info("request failed")
+			} else {
+				entry.Info("request finished")
+			}
+		})
+	}
+}
+
+// clientIP takes address from X-Forwarded-For only behind trusted proxy, as client may set the header itself
+func clientIP(r *http.Request, trustedProxies TrustedProxies) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return trustedProxies.ClientIP(host, false, r.Header.Values("X-Forwarded-For"))
+}
+
+type accessLogRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *accessLogRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *accessLogRecorder) Write(b []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+func (r *accessLogRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,67 @@
+package transport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAccessLogClientIP(t *testing.T) {
+	trustedProxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		want         string
+	}{
+		{
+			name:         "spoofed forwarded for of direct client",
+			remoteAddr:   "203.0.113.7:5000",
+			forwardedFor: []string{"198.51.100.1"},
+			want:         "203.0.113.7",
+		},
+		{
+			name:         "trusted proxies",
+			remoteAddr:   "192.168.1.1:5000",
+			forwardedFor: []string{"198.51.100.1, 203.0.113.7", "10.1.2.3"},
+			want:         "203.0.113.7",
+		},
+		{
+			name:       "trusted proxy without forwarded for",
+			remoteAddr: "10.0.0.5:5000",
+			want:       "10.0.0.5",
+		},
+		{
+			name:         "only trusted hops",
+			remoteAddr:   "10.0.0.5:5000",
+			forwardedFor: []string{"10.0.0.6"},
+			want:         "10.0.0.6",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/contents", nil)
+			r.RemoteAddr = testCase.remoteAddr
+			for _, value := range testCase.forwardedFor {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+
+			if got := clientIP(r, trustedProxies); got != testCase.want {
+				t.Errorf("got %q, want %q", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxiesInvalid(t *testing.T) {
+	for _, address := range []string{"10.0.0.0/33", "proxy.local", ""} {
+		if _, err := ParseTrustedProxies([]string{address}); err == nil {
+			t.Errorf("expected error for %q", address)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net/textproto"
+
+	"github.com/CuriosityMusicStreaming/ComponentsPool/pkg/infrastructure/activity"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+
+	activityIDMetadataKey = "activityID"
+	requestIDMetadataKey  = "x-request-id"
+
+	contentDispositionHeader      = "Content-Disposition"
+	contentDispositionMetadataKey = "content-disposition"
+)
+
+type activityIDContextKey struct{}
+
+// ActivityIDFromContext returns activity ID of request handled by grpc server
+func ActivityIDFromContext(ctx context.Context) (activity.ID, bool) {
+	activityID, ok := ctx.Value(activityIDContextKey{}).(activity.ID)
+	return activityID, ok
+}
+
+func withActivityID(ctx context.Context, activityID activity.ID) context.Context {
+	return context.WithValue(ctx, activityIDContextKey{}, activityID)
+}
+
+// receiveActivityID takes activity ID passed by caller in X-Request-ID or activityID metadata,
+// invalid or missing ID is replaced with new one
+func receiveActivityID(ctx context.Context) activity.ID {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, key := range []string{requestIDMetadataKey, activityIDMetadataKey} {
+		values := md.Get(key)
+		if len(values) == 0 {
+			continue
+		}
+		if activityID, err := activity.ParseActivityID(values[0]); err == nil {
+			return activityID
+		}
+	}
+	return activity.NewActivityID()
+}
+
+// IncomingHeaderMatcher passes X-Request-ID header of REST request to grpc metadata
+func IncomingHeaderMatcher(key string) (string, bool) {
+	if textproto.CanonicalMIMEHeaderKey(key) == textproto.CanonicalMIMEHeaderKey(RequestIDHeader) {
+		return requestIDMetadataKey, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// OutgoingHeaderMatcher passes x-request-id grpc response header to REST response as X-Request-ID
+// and content-disposition as is, so REST clients can save exported files
+func OutgoingHeaderMatcher(key string) (string, bool) {
+	switch key {
+	case requestIDMetadataKey:
+		return RequestIDHeader, true
+	case contentDispositionMetadataKey:
+		return contentDispositionHeader, true
+	}
+	return fmt.Sprintf("%s%s", runtime.MetadataHeaderPrefix, key), true
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	commonauth "github.com/CuriosityMusicStreaming/ComponentsPool/pkg/app/auth"
+	"github.com/google/uuid"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
@@ -14,6 +15,7 @@ import (
 	contentserviceapi "apigateway/api/contentservice"
 	playlistserviceapi "apigateway/api/playlistservice"
 	userserviceapi "apigateway/api/userservice"
+	"apigateway/pkg/apigateway/infrastructure/audit"
 	"apigateway/pkg/apigateway/infrastructure/auth"
 )
 
@@ -28,7 +30,16 @@ func NewAPIGatewayServer(
 	authenticationServiceClient authenticationserviceapi.AuthenticationServiceClient,
 	authenticationService auth.AuthenticationService,
 	userDescriptorSerializer commonauth.UserDescriptorSerializer,
+	auditLog audit.Log,
+	auditAdminUserIDs []uuid.UUID,
+	instance string,
+	pageTokenKey []byte,
 ) apigateway.APIGatewayServer {
+	auditAdmins := make(map[uuid.UUID]struct{}, len(auditAdminUserIDs))
+	for _, userID := range auditAdminUserIDs {
+		auditAdmins[userID] = struct{}{}
+	}
+
 	return &apiGatewayServer{
 		contentServiceClient:        contentServiceClient,
 		userServiceClient:           userServiceClient,
@@ -36,6 +47,10 @@ func NewAPIGatewayServer(
 		authenticationServiceClient: authenticationServiceClient,
 		authenticationService:       authenticationService,
 		userDescriptorSerializer:    userDescriptorSerializer,
+		auditLog:                    auditLog,
+		auditAdmins:                 auditAdmins,
+		instance:                    instance,
+		pageTokens:                  pageTokenCodec{key: pageTokenKey},
 	}
 }
 
@@ -46,6 +61,10 @@ type apiGatewayServer struct {
 	authenticationServiceClient authenticationserviceapi.AuthenticationServiceClient
 	authenticationService       auth.AuthenticationService
 	userDescriptorSerializer    commonauth.UserDescriptorSerializer
+	auditLog                    audit.Log
+	auditAdmins                 map[uuid.UUID]struct{}
+	instance                    string
+	pageTokens                  pageTokenCodec
 }
 
 func (server *apiGatewayServer) AuthenticateUser(ctx context.Context, req *apigateway.AuthenticateUserRequest) (*apigateway.AuthenticateUserResponse, error) {
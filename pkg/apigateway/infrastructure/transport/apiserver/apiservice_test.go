@@ -0,0 +1,229 @@
+package apiserver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	commonauth "github.com/CuriosityMusicStreaming/ComponentsPool/pkg/app/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	contentserviceapi "apigateway/api/contentservice"
+	api "apigateway/api/playlistservice"
+	"apigateway/pkg/apigateway/infrastructure/auth"
+)
+
+const testUserID = "3f2504e0-4f89-11d3-9a0c-0305e82c3301"
+
+func newTestServer(playlistClient api.PlayListServiceClient) *apiGatewayServer {
+	return &apiGatewayServer{
+		playlistServiceClient:    playlistClient,
+		authenticationService:    auth.NewAuthenticationService(auth.TypeBearer),
+		userDescriptorSerializer: commonauth.NewUserDescriptorSerializer(),
+	}
+}
+
+func testUserContext() context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(authorizationHeaderName, "Bearer "+testUserID))
+}
+
+// fakePlaylistClient keeps playlists in memory, playlists get IDs p1, p2... and their items get IDs p1-i1, p1-i2...
+type fakePlaylistClient struct {
+	mu        sync.Mutex
+	playlists map[string]*api.GetPlaylistResponse
+	// failures are returned instead of calls like "AddToPlaylist c1", see call
+	failures map[string]error
+	calls    []string
+	created  int
+	// added counts items ever added to playlist, so IDs of removed items are not reused
+	added map[string]int
+	clock uint64
+}
+
+func newFakePlaylistClient(failures map[string]error) *fakePlaylistClient {
+	return &fakePlaylistClient{
+		playlists: map[string]*api.GetPlaylistResponse{},
+		failures:  failures,
+		added:     map[string]int{},
+	}
+}
+
+// addPlaylist creates playlist with items of contents in their order
+func (c *fakePlaylistClient) addPlaylist(name string, contentIDs ...string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	playlistID := c.createPlaylist(name)
+	for _, contentID := range contentIDs {
+		c.addItem(playlistID, contentID)
+	}
+	return playlistID
+}
+
+// contentIDs returns contents of playlist items in order of their creation
+func (c *fakePlaylistClient) contentIDs(playlistID string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	playlist, ok := c.playlists[playlistID]
+	if !ok {
+		return nil
+	}
+	contentIDs := make([]string, 0, len(playlist.PlaylistItems))
+	for _, item := range playlist.PlaylistItems {
+		contentIDs = append(contentIDs, item.ContentID)
+	}
+	return contentIDs
+}
+
+func (c *fakePlaylistClient) callsOf(method string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	count := 0
+	for _, call := range c.calls {
+		if strings.HasPrefix(call, method+" ") {
+			count++
+		}
+	}
+	return count
+}
+
+// call records call of method with target, which is content ID for AddToPlaylist, item ID for RemoveFromPlaylist
+// and playlist ID for others, and returns failure set for it
+func (c *fakePlaylistClient) call(method, target string) error {
+	key := method + " " + target
+	c.calls = append(c.calls, key)
+	return c.failures[key]
+}
+
+func (c *fakePlaylistClient) createPlaylist(name string) string {
+	c.created++
+	c.clock++
+	playlistID := fmt.Sprintf("p%d", c.created)
+	c.playlists[playlistID] = &api.GetPlaylistResponse{
+		Name:               name,
+		OwnerID:            testUserID,
+		CreatedAtTimestamp: c.clock,
+		UpdatedAtTimestamp: c.clock,
+	}
+	return playlistID
+}
+
+func (c *fakePlaylistClient) addItem(playlistID, contentID string) string {
+	c.clock++
+	playlist := c.playlists[playlistID]
+	c.added[playlistID]++
+	playlistItemID := fmt.Sprintf("%s-i%d", playlistID, c.added[playlistID])
+	playlist.PlaylistItems = append(playlist.PlaylistItems, &api.PlaylistItem{
+		PlaylistItemID:     playlistItemID,
+		ContentID:          contentID,
+		CreatedAtTimestamp: c.clock,
+	})
+	return playlistItemID
+}
+
+func (c *fakePlaylistClient) CreatePlaylist(_ context.Context, in *api.CreatePlaylistRequest, _ ...grpc.CallOption) (*api.CreatePlaylistResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("CreatePlaylist", in.Name); err != nil {
+		return nil, err
+	}
+	return &api.CreatePlaylistResponse{PlaylistID: c.createPlaylist(in.Name)}, nil
+}
+
+func (c *fakePlaylistClient) GetPlaylist(_ context.Context, in *api.GetPlaylistRequest, _ ...grpc.CallOption) (*api.GetPlaylistResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("GetPlaylist", in.PlaylistID); err != nil {
+		return nil, err
+	}
+	playlist, ok := c.playlists[in.PlaylistID]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "playlist %s not found", in.PlaylistID)
+	}
+	return proto.Clone(playlist).(*api.GetPlaylistResponse), nil
+}
+
+func (c *fakePlaylistClient) GetUserPlaylists(context.Context, *api.GetUserPlaylistsRequest, ...grpc.CallOption) (*api.GetUserPlaylistsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "GetUserPlaylists is not implemented")
+}
+
+func (c *fakePlaylistClient) AddToPlaylist(_ context.Context, in *api.AddToPlaylistRequest, _ ...grpc.CallOption) (*api.AddToPlaylistResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("AddToPlaylist", in.ContentID); err != nil {
+		return nil, err
+	}
+	if _, ok := c.playlists[in.PlaylistID]; !ok {
+		return nil, status.Errorf(codes.NotFound, "playlist %s not found", in.PlaylistID)
+	}
+	return &api.AddToPlaylistResponse{PlaylistItemID: c.addItem(in.PlaylistID, in.ContentID)}, nil
+}
+
+func (c *fakePlaylistClient) SetPlaylistName(_ context.Context, in *api.SetPlaylistNameRequest, _ ...grpc.CallOption) (*emptypb.Empty, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("SetPlaylistName", in.PlaylistID); err != nil {
+		return nil, err
+	}
+	playlist, ok := c.playlists[in.PlaylistID]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "playlist %s not found", in.PlaylistID)
+	}
+	playlist.Name = in.NewName
+	return &emptypb.Empty{}, nil
+}
+
+func (c *fakePlaylistClient) RemoveFromPlaylist(_ context.Context, in *api.RemoveFromPlaylistRequest, _ ...grpc.CallOption) (*emptypb.Empty, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("RemoveFromPlaylist", in.PlaylistItemID); err != nil {
+		return nil, err
+	}
+	for _, playlist := range c.playlists {
+		for i, item := range playlist.PlaylistItems {
+			if item.PlaylistItemID == in.PlaylistItemID {
+				playlist.PlaylistItems = append(playlist.PlaylistItems[:i], playlist.PlaylistItems[i+1:]...)
+				return &emptypb.Empty{}, nil
+			}
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "playlist item %s not found", in.PlaylistItemID)
+}
+
+func (c *fakePlaylistClient) RemovePlaylist(_ context.Context, in *api.RemovePlaylistRequest, _ ...grpc.CallOption) (*emptypb.Empty, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("RemovePlaylist", in.PlaylistID); err != nil {
+		return nil, err
+	}
+	if _, ok := c.playlists[in.PlaylistID]; !ok {
+		return nil, status.Errorf(codes.NotFound, "playlist %s not found", in.PlaylistID)
+	}
+	delete(c.playlists, in.PlaylistID)
+	return &emptypb.Empty{}, nil
+}
+
+// fakeContentClient serves GetContentList only, lists with any of failedIDs fail as a whole like chunks of content service
+type fakeContentClient struct {
+	contentserviceapi.ContentServiceClient
+	contents  map[string]*contentserviceapi.Content
+	failedIDs map[string]struct{}
+}
+
+func (c *fakeContentClient) GetContentList(_ context.Context, in *contentserviceapi.GetContentListRequest, _ ...grpc.CallOption) (*contentserviceapi.GetContentListResponse, error) {
+	resp := &contentserviceapi.GetContentListResponse{}
+	for _, contentID := range in.ContentIDs {
+		if _, ok := c.failedIDs[contentID]; ok {
+			return nil, status.Error(codes.Unavailable, "content service is unavailable")
+		}
+		if content, ok := c.contents[contentID]; ok {
+			resp.Contents = append(resp.Contents, content)
+		}
+	}
+	return resp, nil
+}
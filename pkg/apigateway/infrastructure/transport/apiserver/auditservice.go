@@ -0,0 +1,63 @@
+package apiserver
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"apigateway/api/apigateway"
+	"apigateway/pkg/apigateway/infrastructure/audit"
+)
+
+const (
+	maxAuditLogLimit = 1000
+)
+
+// GetAuditLog queries audit log of this instance only, response names the instance
+func (server *apiGatewayServer) GetAuditLog(ctx context.Context, req *apigateway.GetAuditLogRequest) (*apigateway.GetAuditLogResponse, error) {
+	userDesc, err := server.authenticateUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := server.auditAdmins[userDesc.UserID]; !ok {
+		return nil, status.Errorf(codes.PermissionDenied, "user is not audit admin")
+	}
+
+	limit := int(req.Limit)
+	if limit == 0 || limit > maxAuditLogLimit {
+		limit = maxAuditLogLimit
+	}
+
+	records, err := server.auditLog.Query(audit.Filter{
+		UserID:     req.UserID,
+		ResourceID: req.ResourceID,
+		Limit:      limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &apigateway.GetAuditLogResponse{
+		Records:  convertToAuditRecordsAPIGateway(records),
+		Instance: server.instance,
+	}, nil
+}
+
+func convertToAuditRecordsAPIGateway(records []audit.Record) []*apigateway.AuditRecord {
+	result := make([]*apigateway.AuditRecord, 0, len(records))
+	for _, record := range records {
+		result = append(result, &apigateway.AuditRecord{
+			Timestamp:   uint64(record.Timestamp.Unix()),
+			ActorUserID: record.ActorUserID,
+			Method:      record.Method,
+			TargetIDs:   record.TargetIDs,
+			Outcome:     record.Outcome,
+			ClientIP:    record.ClientIP,
+			ActivityID:  record.ActivityID,
+			Hash:        record.Hash,
+		})
+	}
+	return result
+}
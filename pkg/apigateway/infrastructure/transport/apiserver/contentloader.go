@@ -0,0 +1,71 @@
+package apiserver
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+
+	contentserviceapi "apigateway/api/contentservice"
+)
+
+const (
+	contentListChunkSize   = 100
+	contentListConcurrency = 4
+)
+
+// loadContents fetches contents by unique IDs in chunks, at most contentListConcurrency chunks in parallel.
+// Deleted contents are missing in result, IDs of failed chunks are returned with chunk error
+func (server *apiGatewayServer) loadContents(
+	ctx context.Context,
+	contentIDs []string,
+) (map[string]*contentserviceapi.Content, map[string]error) {
+	uniqueIDs := uniqueStrings(contentIDs)
+
+	var mutex sync.Mutex
+	contents := make(map[string]*contentserviceapi.Content, len(uniqueIDs))
+	errs := make(map[string]error)
+
+	var group errgroup.Group
+	group.SetLimit(contentListConcurrency)
+	for start := 0; start < len(uniqueIDs); start += contentListChunkSize {
+		end := start + contentListChunkSize
+		if end > len(uniqueIDs) {
+			end = len(uniqueIDs)
+		}
+		chunk := uniqueIDs[start:end]
+
+		group.Go(func() error {
+			resp, err := server.contentServiceClient.GetContentList(ctx, &contentserviceapi.GetContentListRequest{ContentIDs: chunk})
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				for _, contentID := range chunk {
+					errs[contentID] = err
+				}
+				return nil
+			}
+			for _, content := range resp.Contents {
+				contents[content.ContentID] = content
+			}
+			return nil
+		})
+	}
+	_ = group.Wait()
+
+	return contents, errs
+}
+
+func uniqueStrings(values []string) []string {
+	result := make([]string, 0, len(values))
+	seen := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		if _, ok := seen[value]; ok {
+			continue
+		}
+		seen[value] = struct{}{}
+		result = append(result, value)
+	}
+	return result
+}
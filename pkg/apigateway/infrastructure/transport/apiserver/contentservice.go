@@ -2,12 +2,23 @@ package apiserver
 
 import (
 	"context"
+	"fmt"
+	"sort"
 
+	commonauth "github.com/CuriosityMusicStreaming/ComponentsPool/pkg/app/auth"
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 
 	"apigateway/api/apigateway"
 	contentserviceapi "apigateway/api/contentservice"
+	"apigateway/pkg/apigateway/infrastructure/listing"
+)
+
+const (
+	maxContentsPerRequest = 1000
 )
 
 var (
@@ -95,12 +106,18 @@ func (server *apiGatewayServer) SetContentAvailabilityType(ctx context.Context,
 	return &emptypb.Empty{}, err
 }
 
-func (server *apiGatewayServer) GetAuthorContent(ctx context.Context, _ *apigateway.GetAuthorContentRequest) (*apigateway.GetAuthorContentResponse, error) {
+func (server *apiGatewayServer) GetAuthorContent(ctx context.Context, req *apigateway.GetAuthorContentRequest) (*apigateway.GetAuthorContentResponse, error) {
 	userToken, err := server.authenticateUser(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	// Contents are ordered by ID by default
+	query, err := newListingQuery(req.Filter, req.OrderBy, "", contentListingSchema)
+	if err != nil {
+		return nil, err
+	}
+
 	serializedToken, err := server.userDescriptorSerializer.Serialize(userToken)
 	if err != nil {
 		return nil, err
@@ -111,18 +128,185 @@ func (server *apiGatewayServer) GetAuthorContent(ctx context.Context, _ *apigate
 		return nil, err
 	}
 
-	res := make([]*apigateway.Content, 0, len(resp.Contents))
+	records := make([]listing.Record, 0, len(resp.Contents))
+	ids := make([]string, 0, len(resp.Contents))
+	for _, content := range resp.Contents {
+		records = append(records, contentRecord(content))
+		ids = append(ids, content.ContentID)
+	}
+	items := query.apply(records, ids)
+
+	start, end, nextPageToken, err := server.pageTokens.paginate(listingItemKeys(items), pageRequest{
+		PageSize:  req.PageSize,
+		PageToken: req.PageToken,
+		Query:     query.fingerprint,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*apigateway.Content, 0, end-start)
+	for _, item := range items[start:end] {
+		res = append(res, convertToContentAPIGateway(resp.Contents[item.index]))
+	}
+
+	return &apigateway.GetAuthorContentResponse{
+		Contents:      res,
+		NextPageToken: nextPageToken,
+	}, nil
+}
+
+// GetContents returns contents visible to user and errors of IDs which can not be returned,
+// private content of another author is reported as not found
+func (server *apiGatewayServer) GetContents(ctx context.Context, req *apigateway.GetContentsRequest) (*apigateway.GetContentsResponse, error) {
+	userToken, err := server.authenticateUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	contentIDs := uniqueStrings(req.ContentIDs)
+	if len(contentIDs) > maxContentsPerRequest {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d content IDs are allowed", maxContentsPerRequest)
+	}
+
+	// Malformed ID would fail the whole chunk in content service, so such IDs are reported without loading
+	validIDs := make([]string, 0, len(contentIDs))
+	malformedIDs := make(map[string]struct{})
+	for _, contentID := range contentIDs {
+		if _, err := uuid.Parse(contentID); err != nil {
+			malformedIDs[contentID] = struct{}{}
+			continue
+		}
+		validIDs = append(validIDs, contentID)
+	}
+
+	contents, errs := server.loadContents(ctx, validIDs)
+
+	resp := &apigateway.GetContentsResponse{}
+	for _, contentID := range contentIDs {
+		if _, ok := malformedIDs[contentID]; ok {
+			resp.Errors = append(resp.Errors, &apigateway.ContentError{
+				ContentID: contentID,
+				Code:      int32(codes.InvalidArgument),
+				Message:   "invalid content ID",
+			})
+			continue
+		}
+
+		if err, ok := errs[contentID]; ok {
+			st := status.Convert(err)
+			resp.Errors = append(resp.Errors, &apigateway.ContentError{
+				ContentID: contentID,
+				Code:      int32(st.Code()),
+				Message:   st.Message(),
+			})
+			continue
+		}
+
+		content, ok := contents[contentID]
+		if !ok || !contentVisible(content, userToken.UserID.String()) {
+			resp.Errors = append(resp.Errors, &apigateway.ContentError{
+				ContentID: contentID,
+				Code:      int32(codes.NotFound),
+				Message:   "content not found",
+			})
+			continue
+		}
+		resp.Contents = append(resp.Contents, convertToContentAPIGateway(content))
+	}
+
+	return resp, nil
+}
+
+// GetCreatorCatalog returns public contents of author ordered by ID, it does not require authentication
+func (server *apiGatewayServer) GetCreatorCatalog(ctx context.Context, req *apigateway.GetCreatorCatalogRequest) (*apigateway.GetCreatorCatalogResponse, error) {
+	authorID, err := uuid.Parse(req.AuthorID)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid author ID")
+	}
+
+	types := make(map[contentserviceapi.ContentType]struct{}, len(req.Types))
+	for _, apiType := range req.Types {
+		contentType, ok := apiServiceToContentServiceContentTypeMap[apiType]
+		if !ok {
+			return nil, ErrUnknownContentType
+		}
+		types[contentType] = struct{}{}
+	}
+
+	// Content service returns contents only of token owner, so token of author is used.
+	// Only public contents leave gateway, so caller gets nothing author has not published
+	serializedToken, err := server.userDescriptorSerializer.Serialize(commonauth.UserDescriptor{UserID: authorID})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := server.contentServiceClient.GetAuthorContent(ctx, &contentserviceapi.GetAuthorContentRequest{UserToken: serializedToken})
+	if err != nil {
+		return nil, err
+	}
+
+	contents := make([]*contentserviceapi.Content, 0, len(resp.Contents))
 	for _, content := range resp.Contents {
-		res = append(res, &apigateway.Content{
-			ContentID:        content.ContentID,
-			Name:             content.Name,
-			AuthorID:         content.AuthorID,
-			Type:             contentServiceContentTypeToAPIServiceMap[content.Type],
-			AvailabilityType: contentServiceAvailabilityTypeToAPIServiceMap[content.AvailabilityType],
-		})
+		if content.AvailabilityType != contentserviceapi.ContentAvailabilityType_Public {
+			continue
+		}
+		if _, ok := types[content.Type]; len(types) != 0 && !ok {
+			continue
+		}
+		contents = append(contents, content)
 	}
+	sortContents(contents)
+
+	start, end, nextPageToken, err := server.pageTokens.paginate(contentKeys(contents), pageRequest{
+		PageSize:        req.PageSize,
+		PageToken:       req.PageToken,
+		DefaultPageSize: defaultPageSize,
+		Query:           fmt.Sprint(req.AuthorID, req.Types),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*apigateway.Content, 0, end-start)
+	for _, content := range contents[start:end] {
+		res = append(res, convertToContentAPIGateway(content))
+	}
+
+	return &apigateway.GetCreatorCatalogResponse{
+		Contents:      res,
+		NextPageToken: nextPageToken,
+	}, nil
+}
+
+func convertToContentAPIGateway(content *contentserviceapi.Content) *apigateway.Content {
+	return &apigateway.Content{
+		ContentID:        content.ContentID,
+		Name:             content.Name,
+		AuthorID:         content.AuthorID,
+		Type:             contentServiceContentTypeToAPIServiceMap[content.Type],
+		AvailabilityType: contentServiceAvailabilityTypeToAPIServiceMap[content.AvailabilityType],
+	}
+}
+
+// sortContents orders contents by ID, which is page key of content listings
+func sortContents(contents []*contentserviceapi.Content) {
+	sort.Slice(contents, func(i, j int) bool {
+		return contents[i].ContentID < contents[j].ContentID
+	})
+}
+
+func contentKeys(contents []*contentserviceapi.Content) []string {
+	keys := make([]string, 0, len(contents))
+	for _, content := range contents {
+		keys = append(keys, content.ContentID)
+	}
+	return keys
+}
 
-	return &apigateway.GetAuthorContentResponse{Contents: res}, nil
+// contentVisible reports whether user can see content, private content is visible only to its author
+func contentVisible(content *contentserviceapi.Content, userID string) bool {
+	return content.AvailabilityType == contentserviceapi.ContentAvailabilityType_Public || content.AuthorID == userID
 }
 
 var apiServiceToContentServiceContentTypeMap = map[apigateway.ContentType]contentserviceapi.ContentType{
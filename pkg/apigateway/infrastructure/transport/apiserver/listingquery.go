@@ -0,0 +1,130 @@
+package apiserver
+
+import (
+	"sort"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"apigateway/api/apigateway"
+	contentserviceapi "apigateway/api/contentservice"
+	api "apigateway/api/playlistservice"
+	"apigateway/pkg/apigateway/infrastructure/listing"
+)
+
+const (
+	listingItemKeySeparator = "/"
+)
+
+var playlistListingSchema = listing.Schema{
+	"name":               {Kind: listing.KindString},
+	"ownerID":            {Kind: listing.KindString},
+	"createdAtTimestamp": {Kind: listing.KindNumber},
+	"updatedAtTimestamp": {Kind: listing.KindNumber},
+}
+
+var contentListingSchema = listing.Schema{
+	"name":     {Kind: listing.KindString},
+	"authorID": {Kind: listing.KindString},
+	"type": {Kind: listing.KindEnum, Values: []string{
+		apigateway.ContentType_Song.String(),
+		apigateway.ContentType_Podcast.String(),
+	}},
+	"availabilityType": {Kind: listing.KindEnum, Values: []string{
+		apigateway.ContentAvailabilityType_Public.String(),
+		apigateway.ContentAvailabilityType_Private.String(),
+	}},
+}
+
+// listingQuery filters and orders listing evaluated in gateway since backends return whole listings
+type listingQuery struct {
+	filter  listing.Filter
+	orderBy listing.OrderBy
+	// fingerprint binds page tokens to expressions they were issued for
+	fingerprint string
+}
+
+type listingItem struct {
+	index int
+	// key is page key of item, it orders items by order by and then by ID
+	key string
+}
+
+func newListingQuery(filter, orderBy, defaultOrderBy string, schema listing.Schema) (listingQuery, error) {
+	parsedFilter, err := listing.ParseFilter(filter, schema)
+	if err != nil {
+		return listingQuery{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if orderBy == "" {
+		orderBy = defaultOrderBy
+	}
+	parsedOrderBy, err := listing.ParseOrderBy(orderBy, schema)
+	if err != nil {
+		return listingQuery{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return listingQuery{
+		filter:      parsedFilter,
+		orderBy:     parsedOrderBy,
+		fingerprint: filter + "\n" + orderBy,
+	}, nil
+}
+
+// apply returns items matching filter in page order
+func (q listingQuery) apply(records []listing.Record, ids []string) []listingItem {
+	items := make([]listingItem, 0, len(records))
+	for i, record := range records {
+		if !q.filter.Match(record) {
+			continue
+		}
+		items = append(items, listingItem{
+			index: i,
+			key:   q.orderBy.Key(record) + listingItemKeySeparator + ids[i],
+		})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].key < items[j].key
+	})
+	return items
+}
+
+func listingItemKeys(items []listingItem) []string {
+	keys := make([]string, 0, len(items))
+	for _, item := range items {
+		keys = append(keys, item.key)
+	}
+	return keys
+}
+
+func playlistRecord(playlist *api.Playlist) listing.Record {
+	return func(field string) interface{} {
+		switch field {
+		case "name":
+			return playlist.Name
+		case "ownerID":
+			return playlist.OwnerID
+		case "createdAtTimestamp":
+			return playlist.CreatedAtTimestamp
+		case "updatedAtTimestamp":
+			return playlist.UpdatedAtTimestamp
+		}
+		return nil
+	}
+}
+
+func contentRecord(content *contentserviceapi.Content) listing.Record {
+	return func(field string) interface{} {
+		switch field {
+		case "name":
+			return content.Name
+		case "authorID":
+			return content.AuthorID
+		case "type":
+			return contentServiceContentTypeToAPIServiceMap[content.Type].String()
+		case "availabilityType":
+			return contentServiceAvailabilityTypeToAPIServiceMap[content.AvailabilityType].String()
+		}
+		return nil
+	}
+}
@@ -0,0 +1,112 @@
+package apiserver
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+
+	pageTokenSeparator = "."
+)
+
+// pageCursor points after last item of previous page by its sort key, so pages stay stable when items are added or removed.
+// The same cursor can be passed to backend once it supports pagination
+type pageCursor struct {
+	After string `json:"a"`
+	// Query is fingerprint of request parameters, token can not be used with other filters
+	Query string `json:"q,omitempty"`
+}
+
+type pageRequest struct {
+	PageSize  uint32
+	PageToken string
+	// DefaultPageSize is used when PageSize is 0, 0 means all items
+	DefaultPageSize int
+	Query           string
+}
+
+// pageTokenCodec signs cursors, so clients can not forge them
+type pageTokenCodec struct {
+	key []byte
+}
+
+func (c pageTokenCodec) encode(cursor pageCursor) string {
+	payload, _ := json.Marshal(cursor)
+	encodedPayload := base64.RawURLEncoding.EncodeToString(payload)
+	return encodedPayload + pageTokenSeparator + base64.RawURLEncoding.EncodeToString(c.sign(encodedPayload))
+}
+
+func (c pageTokenCodec) decode(token string) (pageCursor, error) {
+	parts := strings.Split(token, pageTokenSeparator)
+	if len(parts) != 2 {
+		return pageCursor{}, errInvalidPageToken()
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, c.sign(parts[0])) {
+		return pageCursor{}, errInvalidPageToken()
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return pageCursor{}, errInvalidPageToken()
+	}
+
+	var cursor pageCursor
+	if err := json.Unmarshal(payload, &cursor); err != nil {
+		return pageCursor{}, errInvalidPageToken()
+	}
+	return cursor, nil
+}
+
+func (c pageTokenCodec) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	_, _ = mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// paginate returns bounds of page in items sorted by ascending unique keys and token of next page,
+// token is empty when page is the last one
+func (c pageTokenCodec) paginate(keys []string, req pageRequest) (start, end int, nextPageToken string, err error) {
+	size := int(req.PageSize)
+	if size == 0 {
+		size = req.DefaultPageSize
+	}
+	if size > maxPageSize {
+		size = maxPageSize
+	}
+
+	if req.PageToken != "" {
+		cursor, err := c.decode(req.PageToken)
+		if err != nil {
+			return 0, 0, "", err
+		}
+		if cursor.Query != req.Query {
+			return 0, 0, "", status.Errorf(codes.InvalidArgument, "page token does not match request")
+		}
+		start = sort.Search(len(keys), func(i int) bool {
+			return keys[i] > cursor.After
+		})
+	}
+
+	end = len(keys)
+	if size != 0 && start+size < len(keys) {
+		end = start + size
+	}
+	if end == len(keys) || end == start {
+		return start, end, "", nil
+	}
+	return start, end, c.encode(pageCursor{After: keys[end-1], Query: req.Query}), nil
+}
+
+func errInvalidPageToken() error {
+	return status.Errorf(codes.InvalidArgument, "invalid page token")
+}
@@ -0,0 +1,109 @@
+package apiserver
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var testPageKeys = []string{"a", "b", "c", "d", "e"}
+
+func TestPaginatePages(t *testing.T) {
+	codec := pageTokenCodec{key: []byte("secret")}
+
+	var pages [][]string
+	token := ""
+	for {
+		start, end, nextPageToken, err := codec.paginate(testPageKeys, pageRequest{PageSize: 2, PageToken: token, Query: "q"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, testPageKeys[start:end])
+		if nextPageToken == "" {
+			break
+		}
+		token = nextPageToken
+	}
+
+	want := [][]string{{"a", "b"}, {"c", "d"}, {"e"}}
+	if len(pages) != len(want) {
+		t.Fatalf("got pages %v, want %v", pages, want)
+	}
+	for i := range want {
+		if strings.Join(pages[i], ",") != strings.Join(want[i], ",") {
+			t.Errorf("got pages %v, want %v", pages, want)
+		}
+	}
+}
+
+func TestPaginateBoundary(t *testing.T) {
+	codec := pageTokenCodec{key: []byte("secret")}
+
+	start, end, nextPageToken, err := codec.paginate(testPageKeys, pageRequest{PageSize: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if start != 0 || end != 5 || nextPageToken != "" {
+		t.Errorf("page of exact size: got %d:%d with token %q, want 0:5 without token", start, end, nextPageToken)
+	}
+
+	// Items after cursor are removed, so the next page is empty
+	token := codec.encode(pageCursor{After: "e"})
+	start, end, nextPageToken, err = codec.paginate(testPageKeys, pageRequest{PageSize: 2, PageToken: token})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if start != end || nextPageToken != "" {
+		t.Errorf("page after last item: got %d:%d with token %q, want empty page without token", start, end, nextPageToken)
+	}
+
+	// Cursor item is removed, so the page starts from the next one
+	token = codec.encode(pageCursor{After: "bb"})
+	start, end, _, err = codec.paginate(testPageKeys, pageRequest{PageSize: 2, PageToken: token})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if start != 2 || end != 4 {
+		t.Errorf("page after removed item: got %d:%d, want 2:4", start, end)
+	}
+
+	_, end, _, err = codec.paginate(testPageKeys, pageRequest{PageSize: maxPageSize + 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if end != len(testPageKeys) {
+		t.Errorf("page size above maximum: got end %d, want %d", end, len(testPageKeys))
+	}
+}
+
+func TestPaginateRejectsTokens(t *testing.T) {
+	codec := pageTokenCodec{key: []byte("secret")}
+	token := codec.encode(pageCursor{After: "b", Query: "q"})
+	payload := strings.Split(token, pageTokenSeparator)[0]
+
+	forgedPayload := base64.RawURLEncoding.EncodeToString([]byte(`{"a":"d","q":"q"}`))
+	testCases := []struct {
+		name  string
+		codec pageTokenCodec
+		token string
+		query string
+	}{
+		{name: "forged cursor", codec: codec, token: forgedPayload + pageTokenSeparator + strings.Split(token, pageTokenSeparator)[1], query: "q"},
+		{name: "missing signature", codec: codec, token: payload, query: "q"},
+		{name: "malformed signature", codec: codec, token: payload + pageTokenSeparator + "!", query: "q"},
+		{name: "other key", codec: pageTokenCodec{key: []byte("other")}, token: token, query: "q"},
+		{name: "other query", codec: codec, token: token, query: "other"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, _, _, err := testCase.codec.paginate(testPageKeys, pageRequest{PageSize: 2, PageToken: testCase.token, Query: testCase.query})
+			if status.Code(err) != codes.InvalidArgument {
+				t.Errorf("got error %v, want InvalidArgument", err)
+			}
+		})
+	}
+}
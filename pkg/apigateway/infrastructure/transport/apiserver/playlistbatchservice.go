@@ -0,0 +1,178 @@
+package apiserver
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"apigateway/api/apigateway"
+	api "apigateway/api/playlistservice"
+)
+
+const (
+	maxPlaylistBatchSize     = 500
+	playlistBatchConcurrency = 4
+
+	compensationTimeout = 10 * time.Second
+)
+
+// BatchAddToPlaylist adds contents at most playlistBatchConcurrency at once or one by one when order is preserved.
+// Rollback is best effort, items which can not be removed stay applied with error of removal
+func (server *apiGatewayServer) BatchAddToPlaylist(ctx context.Context, req *apigateway.BatchAddToPlaylistRequest) (*apigateway.BatchAddToPlaylistResponse, error) {
+	userToken, err := server.authenticateUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.ContentIDs) > maxPlaylistBatchSize {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d content IDs are allowed", maxPlaylistBatchSize)
+	}
+
+	serializedToken, err := server.userDescriptorSerializer.Serialize(userToken)
+	if err != nil {
+		return nil, err
+	}
+
+	concurrency := playlistBatchConcurrency
+	if req.PreserveOrder {
+		concurrency = 1
+	}
+
+	results := runPlaylistBatch(req.ContentIDs, concurrency, req.RollbackOnFailure, func(contentID string) (string, error) {
+		resp, err := server.playlistServiceClient.AddToPlaylist(ctx, &api.AddToPlaylistRequest{
+			PlaylistID: req.PlaylistID,
+			UserToken:  serializedToken,
+			ContentID:  contentID,
+		})
+		if err != nil {
+			return "", err
+		}
+		return resp.PlaylistItemID, nil
+	})
+
+	if req.RollbackOnFailure && playlistBatchFailed(results) {
+		server.rollbackPlaylistBatch(ctx, results, serializedToken)
+	}
+
+	return &apigateway.BatchAddToPlaylistResponse{Results: results}, nil
+}
+
+func (server *apiGatewayServer) BatchRemoveFromPlaylist(ctx context.Context, req *apigateway.BatchRemoveFromPlaylistRequest) (*apigateway.BatchRemoveFromPlaylistResponse, error) {
+	userToken, err := server.authenticateUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	playlistItemIDs := uniqueStrings(req.PlaylistItemIDs)
+	if len(playlistItemIDs) > maxPlaylistBatchSize {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d playlist item IDs are allowed", maxPlaylistBatchSize)
+	}
+
+	serializedToken, err := server.userDescriptorSerializer.Serialize(userToken)
+	if err != nil {
+		return nil, err
+	}
+
+	results := runPlaylistBatch(playlistItemIDs, playlistBatchConcurrency, false, func(playlistItemID string) (string, error) {
+		_, err := server.playlistServiceClient.RemoveFromPlaylist(ctx, &api.RemoveFromPlaylistRequest{
+			PlaylistItemID: playlistItemID,
+			UserToken:      serializedToken,
+		})
+		return "", err
+	})
+
+	return &apigateway.BatchRemoveFromPlaylistResponse{Results: results}, nil
+}
+
+// rollbackPlaylistBatch removes applied items of batch add marking them as rolled back
+func (server *apiGatewayServer) rollbackPlaylistBatch(ctx context.Context, results []*apigateway.BatchPlaylistItemResult, serializedToken string) {
+	ctx, cancel := compensationContext(ctx)
+	defer cancel()
+
+	var applied []*apigateway.BatchPlaylistItemResult
+	var playlistItemIDs []string
+	for _, result := range results {
+		if result.Status == apigateway.PlaylistUpdateStatus_Applied {
+			applied = append(applied, result)
+			playlistItemIDs = append(playlistItemIDs, result.PlaylistItemID)
+		}
+	}
+
+	removals := runPlaylistBatch(playlistItemIDs, playlistBatchConcurrency, false, func(playlistItemID string) (string, error) {
+		_, err := server.playlistServiceClient.RemoveFromPlaylist(ctx, &api.RemoveFromPlaylistRequest{
+			PlaylistItemID: playlistItemID,
+			UserToken:      serializedToken,
+		})
+		return "", err
+	})
+
+	for i, removal := range removals {
+		if removal.Status == apigateway.PlaylistUpdateStatus_Failed {
+			applied[i].Code = removal.Code
+			applied[i].Message = removal.Message
+			continue
+		}
+		applied[i].Status = apigateway.PlaylistUpdateStatus_RolledBack
+	}
+}
+
+// compensationContext keeps values of request context but not its cancellation,
+// so changes are undone when client disconnects or request deadline is exceeded
+func compensationContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), compensationTimeout)
+}
+
+// runPlaylistBatch applies operation to targets starting them in order at most concurrency at once,
+// operation returns ID of added playlist item. After failure the rest of targets are skipped when stopOnFailure is set
+func runPlaylistBatch(
+	targets []string,
+	concurrency int,
+	stopOnFailure bool,
+	apply func(target string) (string, error),
+) []*apigateway.BatchPlaylistItemResult {
+	results := make([]*apigateway.BatchPlaylistItemResult, len(targets))
+	var failed int32
+
+	var group errgroup.Group
+	group.SetLimit(concurrency)
+	for i, target := range targets {
+		result := &apigateway.BatchPlaylistItemResult{Target: target}
+		results[i] = result
+
+		// Go waits for free slot, so failure of previously started operations is seen here
+		group.Go(func() error {
+			if stopOnFailure && atomic.LoadInt32(&failed) != 0 {
+				result.Status = apigateway.PlaylistUpdateStatus_Skipped
+				return nil
+			}
+
+			playlistItemID, err := apply(result.Target)
+			if err != nil {
+				st := status.Convert(err)
+				result.Status = apigateway.PlaylistUpdateStatus_Failed
+				result.Code = int32(st.Code())
+				result.Message = st.Message()
+				atomic.StoreInt32(&failed, 1)
+				return nil
+			}
+			result.PlaylistItemID = playlistItemID
+			return nil
+		})
+	}
+	_ = group.Wait()
+
+	return results
+}
+
+func playlistBatchFailed(results []*apigateway.BatchPlaylistItemResult) bool {
+	for _, result := range results {
+		if result.Status == apigateway.PlaylistUpdateStatus_Failed {
+			return true
+		}
+	}
+	return false
+}
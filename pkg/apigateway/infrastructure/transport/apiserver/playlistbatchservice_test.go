@@ -0,0 +1,147 @@
+package apiserver
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"apigateway/api/apigateway"
+)
+
+func TestBatchAddToPlaylist(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "playlist service is unavailable")
+
+	testCases := []struct {
+		name          string
+		contentIDs    []string
+		preserveOrder bool
+		rollback      bool
+		failures      map[string]error
+		wantStatuses  []apigateway.PlaylistUpdateStatus
+		wantCodes     []codes.Code
+		// wantItems are contents of playlist after batch, compared in order only when order is preserved
+		wantItems []string
+	}{
+		{
+			name:          "preserve order",
+			contentIDs:    []string{"c1", "c2", "c3", "c4", "c5"},
+			preserveOrder: true,
+			wantStatuses: []apigateway.PlaylistUpdateStatus{
+				apigateway.PlaylistUpdateStatus_Applied,
+				apigateway.PlaylistUpdateStatus_Applied,
+				apigateway.PlaylistUpdateStatus_Applied,
+				apigateway.PlaylistUpdateStatus_Applied,
+				apigateway.PlaylistUpdateStatus_Applied,
+			},
+			wantCodes: []codes.Code{codes.OK, codes.OK, codes.OK, codes.OK, codes.OK},
+			wantItems: []string{"c1", "c2", "c3", "c4", "c5"},
+		},
+		{
+			name:       "failure without rollback",
+			contentIDs: []string{"c1", "c2", "c3"},
+			failures:   map[string]error{"AddToPlaylist c2": unavailable},
+			wantStatuses: []apigateway.PlaylistUpdateStatus{
+				apigateway.PlaylistUpdateStatus_Applied,
+				apigateway.PlaylistUpdateStatus_Failed,
+				apigateway.PlaylistUpdateStatus_Applied,
+			},
+			wantCodes: []codes.Code{codes.OK, codes.Unavailable, codes.OK},
+			wantItems: []string{"c1", "c3"},
+		},
+		{
+			name:          "rollback skips items after failure",
+			contentIDs:    []string{"c1", "c2", "c3", "c4"},
+			preserveOrder: true,
+			rollback:      true,
+			failures:      map[string]error{"AddToPlaylist c3": unavailable},
+			wantStatuses: []apigateway.PlaylistUpdateStatus{
+				apigateway.PlaylistUpdateStatus_RolledBack,
+				apigateway.PlaylistUpdateStatus_RolledBack,
+				apigateway.PlaylistUpdateStatus_Failed,
+				apigateway.PlaylistUpdateStatus_Skipped,
+			},
+			wantCodes: []codes.Code{codes.OK, codes.OK, codes.Unavailable, codes.OK},
+			wantItems: []string{},
+		},
+		{
+			name:          "failed removal during rollback",
+			contentIDs:    []string{"c1", "c2", "c3"},
+			preserveOrder: true,
+			rollback:      true,
+			failures: map[string]error{
+				"AddToPlaylist c3":         unavailable,
+				"RemoveFromPlaylist p1-i1": status.Error(codes.DeadlineExceeded, "timeout"),
+			},
+			wantStatuses: []apigateway.PlaylistUpdateStatus{
+				apigateway.PlaylistUpdateStatus_Applied,
+				apigateway.PlaylistUpdateStatus_RolledBack,
+				apigateway.PlaylistUpdateStatus_Failed,
+			},
+			wantCodes: []codes.Code{codes.DeadlineExceeded, codes.OK, codes.Unavailable},
+			wantItems: []string{"c1"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			client := newFakePlaylistClient(testCase.failures)
+			playlistID := client.addPlaylist("batch")
+			server := newTestServer(client)
+
+			resp, err := server.BatchAddToPlaylist(testUserContext(), &apigateway.BatchAddToPlaylistRequest{
+				PlaylistID:        playlistID,
+				ContentIDs:        testCase.contentIDs,
+				PreserveOrder:     testCase.preserveOrder,
+				RollbackOnFailure: testCase.rollback,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(resp.Results) != len(testCase.contentIDs) {
+				t.Fatalf("got %d results, want %d", len(resp.Results), len(testCase.contentIDs))
+			}
+			for i, result := range resp.Results {
+				if result.Target != testCase.contentIDs[i] {
+					t.Errorf("result %d: got target %q, want %q", i, result.Target, testCase.contentIDs[i])
+				}
+				if result.Status != testCase.wantStatuses[i] {
+					t.Errorf("result %d: got status %v, want %v", i, result.Status, testCase.wantStatuses[i])
+				}
+				if codes.Code(result.Code) != testCase.wantCodes[i] {
+					t.Errorf("result %d: got code %v, want %v", i, codes.Code(result.Code), testCase.wantCodes[i])
+				}
+			}
+
+			items := client.contentIDs(playlistID)
+			wantItems := testCase.wantItems
+			if !testCase.preserveOrder {
+				sort.Strings(items)
+				wantItems = append([]string(nil), wantItems...)
+				sort.Strings(wantItems)
+			}
+			if strings.Join(items, ",") != strings.Join(wantItems, ",") {
+				t.Errorf("got playlist items %v, want %v", items, wantItems)
+			}
+		})
+	}
+}
+
+func TestBatchAddToPlaylistTooManyContents(t *testing.T) {
+	client := newFakePlaylistClient(nil)
+	server := newTestServer(client)
+
+	_, err := server.BatchAddToPlaylist(testUserContext(), &apigateway.BatchAddToPlaylistRequest{
+		PlaylistID: client.addPlaylist("batch"),
+		ContentIDs: make([]string, maxPlaylistBatchSize+1),
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("got error %v, want InvalidArgument", err)
+	}
+	if calls := client.callsOf("AddToPlaylist"); calls != 0 {
+		t.Errorf("got %d AddToPlaylist calls, want none", calls)
+	}
+}
@@ -0,0 +1,161 @@
+package apiserver
+
+import (
+	"context"
+	"sort"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"apigateway/api/apigateway"
+	api "apigateway/api/playlistservice"
+)
+
+const (
+	maxMergedPlaylists = 20
+	// maxBuiltPlaylistItems limits sequential adds of copied items, so building playlist fits REST write timeout
+	maxBuiltPlaylistItems = 200
+)
+
+// DuplicatePlaylist copies items of playlist to new playlist in their order
+func (server *apiGatewayServer) DuplicatePlaylist(ctx context.Context, req *apigateway.DuplicatePlaylistRequest) (*apigateway.DuplicatePlaylistResponse, error) {
+	userToken, err := server.authenticateUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	serializedToken, err := server.userDescriptorSerializer.Serialize(userToken)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := server.playlistServiceClient.GetPlaylist(ctx, &api.GetPlaylistRequest{
+		PlaylistID: req.PlaylistID,
+		UserToken:  serializedToken,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	name := req.Name
+	if name == "" {
+		name = source.Name
+	}
+
+	playlistID, err := server.buildPlaylist(ctx, serializedToken, name, playlistContentIDs([]*api.GetPlaylistResponse{source}, req.Dedupe))
+	if err != nil {
+		return nil, err
+	}
+
+	return &apigateway.DuplicatePlaylistResponse{PlaylistID: playlistID}, nil
+}
+
+// MergePlaylists creates playlist with items of playlists in order of request, source playlists are kept
+func (server *apiGatewayServer) MergePlaylists(ctx context.Context, req *apigateway.MergePlaylistsRequest) (*apigateway.MergePlaylistsResponse, error) {
+	userToken, err := server.authenticateUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.PlaylistIDs) == 0 || len(req.PlaylistIDs) > maxMergedPlaylists {
+		return nil, status.Errorf(codes.InvalidArgument, "from 1 to %d playlist IDs are allowed", maxMergedPlaylists)
+	}
+
+	serializedToken, err := server.userDescriptorSerializer.Serialize(userToken)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make([]*api.GetPlaylistResponse, 0, len(req.PlaylistIDs))
+	for _, playlistID := range req.PlaylistIDs {
+		source, err := server.playlistServiceClient.GetPlaylist(ctx, &api.GetPlaylistRequest{
+			PlaylistID: playlistID,
+			UserToken:  serializedToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+
+	name := req.Name
+	if name == "" {
+		name = sources[0].Name
+	}
+
+	playlistID, err := server.buildPlaylist(ctx, serializedToken, name, playlistContentIDs(sources, req.Dedupe))
+	if err != nil {
+		return nil, err
+	}
+
+	return &apigateway.MergePlaylistsResponse{PlaylistID: playlistID}, nil
+}
+
+// buildPlaylist creates playlist and adds contents one by one to keep their order.
+// If adding fails, created playlist is removed, so user does not get half-built playlist
+func (server *apiGatewayServer) buildPlaylist(ctx context.Context, serializedToken, name string, contentIDs []string) (string, error) {
+	if len(contentIDs) > maxBuiltPlaylistItems {
+		return "", status.Errorf(codes.InvalidArgument, "playlists have %d items, at most %d items can be copied", len(contentIDs), maxBuiltPlaylistItems)
+	}
+
+	created, err := server.playlistServiceClient.CreatePlaylist(ctx, &api.CreatePlaylistRequest{
+		Name:      name,
+		UserToken: serializedToken,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	results := runPlaylistBatch(contentIDs, 1, true, func(contentID string) (string, error) {
+		resp, err := server.playlistServiceClient.AddToPlaylist(ctx, &api.AddToPlaylistRequest{
+			PlaylistID: created.PlaylistID,
+			UserToken:  serializedToken,
+			ContentID:  contentID,
+		})
+		if err != nil {
+			return "", err
+		}
+		return resp.PlaylistItemID, nil
+	})
+
+	for _, result := range results {
+		if result.Status != apigateway.PlaylistUpdateStatus_Failed {
+			continue
+		}
+
+		removeCtx, cancel := compensationContext(ctx)
+		_, err := server.playlistServiceClient.RemovePlaylist(removeCtx, &api.RemovePlaylistRequest{
+			PlaylistID: created.PlaylistID,
+			UserToken:  serializedToken,
+		})
+		cancel()
+		if err != nil {
+			return "", status.Errorf(codes.Code(result.Code), "failed to add content %s: %s, playlist %s is not removed: %s",
+				result.Target, result.Message, created.PlaylistID, status.Convert(err).Message())
+		}
+		return "", status.Errorf(codes.Code(result.Code), "failed to add content %s: %s", result.Target, result.Message)
+	}
+
+	return created.PlaylistID, nil
+}
+
+// playlistContentIDs returns content IDs of playlists items in order of playlists and then of item creation
+func playlistContentIDs(playlists []*api.GetPlaylistResponse, dedupe bool) []string {
+	var contentIDs []string
+	for _, playlist := range playlists {
+		items := make([]*api.PlaylistItem, len(playlist.PlaylistItems))
+		copy(items, playlist.PlaylistItems)
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].CreatedAtTimestamp < items[j].CreatedAtTimestamp
+		})
+
+		for _, item := range items {
+			contentIDs = append(contentIDs, item.ContentID)
+		}
+	}
+
+	if dedupe {
+		return uniqueStrings(contentIDs)
+	}
+	return contentIDs
+}
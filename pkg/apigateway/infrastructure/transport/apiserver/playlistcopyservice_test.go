@@ -0,0 +1,97 @@
+package apiserver
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestBuildPlaylist(t *testing.T) {
+	maxContentIDs := make([]string, maxBuiltPlaylistItems)
+	for i := range maxContentIDs {
+		maxContentIDs[i] = fmt.Sprintf("c%d", i+1)
+	}
+	unavailable := status.Error(codes.Unavailable, "playlist service is unavailable")
+
+	testCases := []struct {
+		name       string
+		contentIDs []string
+		failures   map[string]error
+		wantCode   codes.Code
+		// wantMessage is part of error message
+		wantMessage string
+		// wantItems are contents of built playlist p1, nil if it must not exist
+		wantItems []string
+		wantAdds  int
+	}{
+		{
+			name:       "contents in order",
+			contentIDs: []string{"c3", "c1", "c2"},
+			wantCode:   codes.OK,
+			wantItems:  []string{"c3", "c1", "c2"},
+			wantAdds:   3,
+		},
+		{
+			name:       "maximum contents",
+			contentIDs: maxContentIDs,
+			wantCode:   codes.OK,
+			wantItems:  maxContentIDs,
+			wantAdds:   maxBuiltPlaylistItems,
+		},
+		{
+			name:        "too many contents",
+			contentIDs:  make([]string, maxBuiltPlaylistItems+1),
+			wantCode:    codes.InvalidArgument,
+			wantMessage: fmt.Sprintf("at most %d items", maxBuiltPlaylistItems),
+		},
+		{
+			name:        "failure removes playlist",
+			contentIDs:  []string{"c1", "c2", "c3"},
+			failures:    map[string]error{"AddToPlaylist c2": unavailable},
+			wantCode:    codes.Unavailable,
+			wantMessage: "failed to add content c2",
+			wantAdds:    2,
+		},
+		{
+			name:       "failed removal keeps playlist",
+			contentIDs: []string{"c1", "c2", "c3"},
+			failures: map[string]error{
+				"AddToPlaylist c2":  unavailable,
+				"RemovePlaylist p1": status.Error(codes.DeadlineExceeded, "timeout"),
+			},
+			wantCode:    codes.Unavailable,
+			wantMessage: "playlist p1 is not removed: timeout",
+			wantItems:   []string{"c1"},
+			wantAdds:    2,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			client := newFakePlaylistClient(testCase.failures)
+			server := newTestServer(client)
+
+			playlistID, err := server.buildPlaylist(testUserContext(), "token", "built", testCase.contentIDs)
+			if status.Code(err) != testCase.wantCode {
+				t.Fatalf("got error %v, want %v", err, testCase.wantCode)
+			}
+			if !strings.Contains(status.Convert(err).Message(), testCase.wantMessage) {
+				t.Errorf("got message %q, want it to contain %q", status.Convert(err).Message(), testCase.wantMessage)
+			}
+			if err == nil && playlistID != "p1" {
+				t.Errorf("got playlist ID %q, want p1", playlistID)
+			}
+
+			items := client.contentIDs("p1")
+			if (items == nil) != (testCase.wantItems == nil) || strings.Join(items, ",") != strings.Join(testCase.wantItems, ",") {
+				t.Errorf("got playlist items %v, want %v", items, testCase.wantItems)
+			}
+			if adds := client.callsOf("AddToPlaylist"); adds != testCase.wantAdds {
+				t.Errorf("got %d AddToPlaylist calls, want %d", adds, testCase.wantAdds)
+			}
+		})
+	}
+}
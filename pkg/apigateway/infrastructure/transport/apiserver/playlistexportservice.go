@@ -0,0 +1,204 @@
+package apiserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"strings"
+	"unicode"
+
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"apigateway/api/apigateway"
+)
+
+const (
+	contentDispositionHeaderName = "content-disposition"
+	attachmentDispositionType    = "attachment"
+
+	exportedContentURIPrefix = "urn:curiosity:content:"
+	exportedPlaylistVersion  = 1
+	defaultExportFileName    = "playlist"
+)
+
+type playlistExportFormat struct {
+	contentType string
+	extension   string
+	encode      func(playlist *apigateway.GetPlaylistDetailedResponse) ([]byte, error)
+}
+
+var playlistExportFormats = map[apigateway.PlaylistExportFormat]playlistExportFormat{
+	apigateway.PlaylistExportFormat_JSON: {contentType: "application/json", extension: "json", encode: encodePlaylistJSON},
+	apigateway.PlaylistExportFormat_M3U8: {contentType: "application/vnd.apple.mpegurl", extension: "m3u8", encode: encodePlaylistM3U8},
+	apigateway.PlaylistExportFormat_XSPF: {contentType: "application/xspf+xml", extension: "xspf", encode: encodePlaylistXSPF},
+}
+
+// ExportPlaylist returns playlist with content names in requested format, file name is sent in content-disposition header
+func (server *apiGatewayServer) ExportPlaylist(ctx context.Context, req *apigateway.ExportPlaylistRequest) (*httpbody.HttpBody, error) {
+	format, ok := playlistExportFormats[req.Format]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown export format %s", req.Format)
+	}
+
+	playlist, err := server.GetPlaylistDetailed(ctx, &apigateway.GetPlaylistDetailedRequest{PlaylistID: req.PlaylistID})
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := format.encode(playlist)
+	if err != nil {
+		return nil, err
+	}
+
+	contentDisposition := attachmentContentDisposition(exportFileName(playlist.Name) + "." + format.extension)
+	err = grpc.SetHeader(ctx, metadata.Pairs(contentDispositionHeaderName, contentDisposition))
+	if err != nil {
+		return nil, err
+	}
+
+	return &httpbody.HttpBody{
+		ContentType: format.contentType,
+		Data:        data,
+	}, nil
+}
+
+// exportedPlaylist is JSON export format, version is increased on incompatible changes
+type exportedPlaylist struct {
+	Version            int                    `json:"version"`
+	Name               string                 `json:"name"`
+	CreatedAtTimestamp uint64                 `json:"createdAtTimestamp"`
+	UpdatedAtTimestamp uint64                 `json:"updatedAtTimestamp"`
+	Items              []exportedPlaylistItem `json:"items"`
+}
+
+type exportedPlaylistItem struct {
+	ContentID        string `json:"contentID"`
+	URI              string `json:"uri"`
+	AddedAtTimestamp uint64 `json:"addedAtTimestamp"`
+	Status           string `json:"status"`
+	// Content fields are empty for deleted and unavailable contents
+	Name     string `json:"name,omitempty"`
+	AuthorID string `json:"authorID,omitempty"`
+	Type     string `json:"type,omitempty"`
+}
+
+func encodePlaylistJSON(playlist *apigateway.GetPlaylistDetailedResponse) ([]byte, error) {
+	exported := exportedPlaylist{
+		Version:            exportedPlaylistVersion,
+		Name:               playlist.Name,
+		CreatedAtTimestamp: playlist.CreatedAtTimestamp,
+		UpdatedAtTimestamp: playlist.UpdatedAtTimestamp,
+		Items:              make([]exportedPlaylistItem, 0, len(playlist.PlaylistItems)),
+	}
+	for _, item := range playlist.PlaylistItems {
+		exportedItem := exportedPlaylistItem{
+			ContentID:        item.ContentID,
+			URI:              exportedContentURIPrefix + item.ContentID,
+			AddedAtTimestamp: item.CreatedAtTimestamp,
+			Status:           item.ContentStatus.String(),
+		}
+		if item.Content != nil {
+			exportedItem.Name = item.Content.Name
+			exportedItem.AuthorID = item.Content.AuthorID
+			exportedItem.Type = item.Content.Type.String()
+		}
+		exported.Items = append(exported.Items, exportedItem)
+	}
+	return json.MarshalIndent(exported, "", "  ")
+}
+
+// encodePlaylistM3U8 writes extended M3U with unknown durations
+func encodePlaylistM3U8(playlist *apigateway.GetPlaylistDetailedResponse) ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteString("#EXTM3U\n")
+	fmt.Fprintf(&buffer, "#PLAYLIST:%s\n", singleLine(playlist.Name))
+	for _, item := range playlist.PlaylistItems {
+		if item.Content == nil {
+			continue
+		}
+		fmt.Fprintf(&buffer, "#EXTINF:-1,%s\n", singleLine(item.Content.Name))
+		fmt.Fprintf(&buffer, "%s%s\n", exportedContentURIPrefix, item.ContentID)
+	}
+	return buffer.Bytes(), nil
+}
+
+type xspfPlaylist struct {
+	XMLName   xml.Name      `xml:"http://xspf.org/ns/0/ playlist"`
+	Version   int           `xml:"version,attr"`
+	Title     string        `xml:"title"`
+	TrackList xspfTrackList `xml:"trackList"`
+}
+
+// xspfTrackList is element, so it is written for empty playlist as XSPF requires
+type xspfTrackList struct {
+	Tracks []xspfTrack `xml:"track"`
+}
+
+type xspfTrack struct {
+	Identifier string `xml:"identifier"`
+	Title      string `xml:"title"`
+}
+
+func encodePlaylistXSPF(playlist *apigateway.GetPlaylistDetailedResponse) ([]byte, error) {
+	exported := xspfPlaylist{
+		Version: 1,
+		Title:   playlist.Name,
+	}
+	for _, item := range playlist.PlaylistItems {
+		if item.Content == nil {
+			continue
+		}
+		exported.TrackList.Tracks = append(exported.TrackList.Tracks, xspfTrack{
+			Identifier: exportedContentURIPrefix + item.ContentID,
+			Title:      item.Content.Name,
+		})
+	}
+
+	data, err := xml.MarshalIndent(exported, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// exportFileName replaces characters which are not allowed in file names
+func exportFileName(name string) string {
+	fileName := strings.Map(func(r rune) rune {
+		if r < ' ' || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if fileName == "" {
+		return defaultExportFileName
+	}
+	return fileName
+}
+
+// attachmentContentDisposition adds ASCII file name for clients not supporting encoded non-ASCII one
+func attachmentContentDisposition(fileName string) string {
+	asciiFileName := strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII {
+			return '_'
+		}
+		return r
+	}, fileName)
+
+	contentDisposition := mime.FormatMediaType(attachmentDispositionType, map[string]string{"filename": asciiFileName})
+	if asciiFileName == fileName {
+		return contentDisposition
+	}
+	encodedFileName := mime.FormatMediaType(attachmentDispositionType, map[string]string{"filename": fileName})
+	return contentDisposition + strings.TrimPrefix(encodedFileName, attachmentDispositionType)
+}
+
+func singleLine(value string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+}
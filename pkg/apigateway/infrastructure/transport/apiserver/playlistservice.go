@@ -2,10 +2,25 @@ package apiserver
 
 import (
 	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 
 	"apigateway/api/apigateway"
 	api "apigateway/api/playlistservice"
+	"apigateway/pkg/apigateway/infrastructure/fieldmask"
+	"apigateway/pkg/apigateway/infrastructure/listing"
+)
+
+const (
+	// defaultPlaylistsOrderBy orders playlists by creation time
+	defaultPlaylistsOrderBy = "createdAtTimestamp"
+
+	playlistItemsField = "playlistItems"
+
+	updatePlaylistNamePath                   = "name"
+	updatePlaylistAddedContentIDsPath        = "addedContentIDs"
+	updatePlaylistRemovedPlaylistItemIDsPath = "removedPlaylistItemIDs"
 )
 
 func (server *apiGatewayServer) CreatePlaylist(ctx context.Context, req *apigateway.CreatePlaylistRequest) (*apigateway.CreatePlaylistResponse, error) {
@@ -38,6 +53,11 @@ func (server *apiGatewayServer) GetPlaylist(ctx context.Context, req *apigateway
 		return nil, err
 	}
 
+	mask, err := newReadMask(&apigateway.GetPlaylistResponse{}, req.ReadMask)
+	if err != nil {
+		return nil, err
+	}
+
 	serializedToken, err := server.userDescriptorSerializer.Serialize(userToken)
 	if err != nil {
 		return nil, err
@@ -51,21 +71,97 @@ func (server *apiGatewayServer) GetPlaylist(ctx context.Context, req *apigateway
 		return nil, err
 	}
 
-	return &apigateway.GetPlaylistResponse{
+	res := &apigateway.GetPlaylistResponse{
 		Name:               resp.Name,
 		OwnerID:            resp.OwnerID,
 		CreatedAtTimestamp: resp.CreatedAtTimestamp,
 		UpdatedAtTimestamp: resp.UpdatedAtTimestamp,
-		PlaylistItems:      convertToPlaylistItemsAPIGateway(resp.PlaylistItems),
+	}
+	if mask.Includes(playlistItemsField) {
+		res.PlaylistItems = convertToPlaylistItemsAPIGateway(resp.PlaylistItems)
+	}
+	mask.Prune(res)
+
+	return res, nil
+}
+
+// GetPlaylistDetailed returns playlist items with their contents, items of deleted, private or not loaded contents are marked by status
+func (server *apiGatewayServer) GetPlaylistDetailed(ctx context.Context, req *apigateway.GetPlaylistDetailedRequest) (*apigateway.GetPlaylistDetailedResponse, error) {
+	userToken, err := server.authenticateUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	serializedToken, err := server.userDescriptorSerializer.Serialize(userToken)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := server.playlistServiceClient.GetPlaylist(ctx, &api.GetPlaylistRequest{
+		PlaylistID: req.PlaylistID,
+		UserToken:  serializedToken,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	contentIDs := make([]string, 0, len(resp.PlaylistItems))
+	for _, item := range resp.PlaylistItems {
+		contentIDs = append(contentIDs, item.ContentID)
+	}
+
+	// Items of failed chunks are marked as not loaded, so unavailable content service does not fail the whole playlist
+	contents, errs := server.loadContents(ctx, contentIDs)
+
+	items := make([]*apigateway.DetailedPlaylistItem, 0, len(resp.PlaylistItems))
+	for _, item := range resp.PlaylistItems {
+		detailedItem := &apigateway.DetailedPlaylistItem{
+			PlaylistItemID:     item.PlaylistItemID,
+			ContentID:          item.ContentID,
+			CreatedAtTimestamp: item.CreatedAtTimestamp,
+		}
+
+		content, ok := contents[item.ContentID]
+		_, failed := errs[item.ContentID]
+		switch {
+		case failed:
+			detailedItem.ContentStatus = apigateway.PlaylistItemContentStatus_NotLoaded
+		case !ok:
+			detailedItem.ContentStatus = apigateway.PlaylistItemContentStatus_Deleted
+		case !contentVisible(content, userToken.UserID.String()):
+			detailedItem.ContentStatus = apigateway.PlaylistItemContentStatus_Unavailable
+		default:
+			detailedItem.ContentStatus = apigateway.PlaylistItemContentStatus_Available
+			detailedItem.Content = convertToContentAPIGateway(content)
+		}
+		items = append(items, detailedItem)
+	}
+
+	return &apigateway.GetPlaylistDetailedResponse{
+		Name:               resp.Name,
+		OwnerID:            resp.OwnerID,
+		CreatedAtTimestamp: resp.CreatedAtTimestamp,
+		UpdatedAtTimestamp: resp.UpdatedAtTimestamp,
+		PlaylistItems:      items,
 	}, nil
 }
 
-func (server *apiGatewayServer) GetUserPlaylists(ctx context.Context, _ *apigateway.GetUserPlaylistsRequest) (*apigateway.GetUserPlaylistsResponse, error) {
+func (server *apiGatewayServer) GetUserPlaylists(ctx context.Context, req *apigateway.GetUserPlaylistsRequest) (*apigateway.GetUserPlaylistsResponse, error) {
 	userToken, err := server.authenticateUser(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	query, err := newListingQuery(req.Filter, req.OrderBy, defaultPlaylistsOrderBy, playlistListingSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	mask, err := newReadMask(&apigateway.Playlist{}, req.ReadMask)
+	if err != nil {
+		return nil, err
+	}
+
 	serializedToken, err := server.userDescriptorSerializer.Serialize(userToken)
 	if err != nil {
 		return nil, err
@@ -78,8 +174,31 @@ func (server *apiGatewayServer) GetUserPlaylists(ctx context.Context, _ *apigate
 		return nil, err
 	}
 
+	records := make([]listing.Record, 0, len(resp.Playlists))
+	ids := make([]string, 0, len(resp.Playlists))
+	for _, playlist := range resp.Playlists {
+		records = append(records, playlistRecord(playlist))
+		ids = append(ids, playlist.PlaylistID)
+	}
+	items := query.apply(records, ids)
+
+	start, end, nextPageToken, err := server.pageTokens.paginate(listingItemKeys(items), pageRequest{
+		PageSize:  req.PageSize,
+		PageToken: req.PageToken,
+		Query:     query.fingerprint,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	playlists := make([]*api.Playlist, 0, end-start)
+	for _, item := range items[start:end] {
+		playlists = append(playlists, resp.Playlists[item.index])
+	}
+
 	return &apigateway.GetUserPlaylistsResponse{
-		Playlists: convertToPlaylistsAPIGateway(resp.Playlists),
+		Playlists:     convertToPlaylistsAPIGateway(playlists, mask),
+		NextPageToken: nextPageToken,
 	}, nil
 }
 
@@ -128,6 +247,110 @@ func (server *apiGatewayServer) SetPlaylistName(ctx context.Context, req *apigat
 	return &emptypb.Empty{}, err
 }
 
+// UpdatePlaylist applies operations selected by update mask one by one. Playlist service has no transactions,
+// so operations applied before failed one stay applied and the rest are skipped
+func (server *apiGatewayServer) UpdatePlaylist(ctx context.Context, req *apigateway.UpdatePlaylistRequest) (*apigateway.UpdatePlaylistResponse, error) {
+	userToken, err := server.authenticateUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := updatePlaylistPaths(req)
+	if err != nil {
+		return nil, err
+	}
+
+	serializedToken, err := server.userDescriptorSerializer.Serialize(userToken)
+	if err != nil {
+		return nil, err
+	}
+
+	var operations []playlistUpdateOperation
+	for _, path := range paths {
+		switch path {
+		case updatePlaylistNamePath:
+			operations = append(operations, playlistUpdateOperation{
+				path:   path,
+				target: req.Name,
+				apply: func() (string, error) {
+					_, err := server.playlistServiceClient.SetPlaylistName(ctx, &api.SetPlaylistNameRequest{
+						PlaylistID: req.PlaylistID,
+						NewName:    req.Name,
+						UserToken:  serializedToken,
+					})
+					return "", err
+				},
+			})
+		case updatePlaylistAddedContentIDsPath:
+			for _, contentID := range req.AddedContentIDs {
+				contentID := contentID
+				operations = append(operations, playlistUpdateOperation{
+					path:   path,
+					target: contentID,
+					apply: func() (string, error) {
+						resp, err := server.playlistServiceClient.AddToPlaylist(ctx, &api.AddToPlaylistRequest{
+							PlaylistID: req.PlaylistID,
+							UserToken:  serializedToken,
+							ContentID:  contentID,
+						})
+						if err != nil {
+							return "", err
+						}
+						return resp.PlaylistItemID, nil
+					},
+				})
+			}
+		case updatePlaylistRemovedPlaylistItemIDsPath:
+			err = server.checkPlaylistItems(ctx, req.PlaylistID, serializedToken, req.RemovedPlaylistItemIDs)
+			if err != nil {
+				return nil, err
+			}
+			for _, playlistItemID := range req.RemovedPlaylistItemIDs {
+				playlistItemID := playlistItemID
+				operations = append(operations, playlistUpdateOperation{
+					path:   path,
+					target: playlistItemID,
+					apply: func() (string, error) {
+						_, err := server.playlistServiceClient.RemoveFromPlaylist(ctx, &api.RemoveFromPlaylistRequest{
+							PlaylistItemID: playlistItemID,
+							UserToken:      serializedToken,
+						})
+						return "", err
+					},
+				})
+			}
+		}
+	}
+
+	resp := &apigateway.UpdatePlaylistResponse{}
+	failed := false
+	for _, operation := range operations {
+		result := &apigateway.PlaylistUpdateResult{
+			Path:   operation.path,
+			Target: operation.target,
+		}
+		resp.Results = append(resp.Results, result)
+
+		if failed {
+			result.Status = apigateway.PlaylistUpdateStatus_Skipped
+			continue
+		}
+
+		playlistItemID, err := operation.apply()
+		if err != nil {
+			st := status.Convert(err)
+			result.Status = apigateway.PlaylistUpdateStatus_Failed
+			result.Code = int32(st.Code())
+			result.Message = st.Message()
+			failed = true
+			continue
+		}
+		result.PlaylistItemID = playlistItemID
+	}
+
+	return resp, nil
+}
+
 func (server *apiGatewayServer) RemoveFromPlaylist(ctx context.Context, req *apigateway.RemoveFromPlaylistRequest) (*emptypb.Empty, error) {
 	userToken, err := server.authenticateUser(ctx)
 	if err != nil {
@@ -166,23 +389,82 @@ func (server *apiGatewayServer) RemovePlaylist(ctx context.Context, req *apigate
 	return &emptypb.Empty{}, err
 }
 
-func convertToPlaylistsAPIGateway(playlists []*api.Playlist) []*apigateway.Playlist {
-	result := make([]*apigateway.Playlist, len(playlists))
-	for i, playlist := range playlists {
-		result[i] = convertToPlaylistAPIGateway(playlist)
+// checkPlaylistItems rejects items of other playlists, playlist service removes any item of user by its ID
+func (server *apiGatewayServer) checkPlaylistItems(ctx context.Context, playlistID, serializedToken string, playlistItemIDs []string) error {
+	if len(playlistItemIDs) == 0 {
+		return nil
 	}
-	return result
+
+	playlist, err := server.playlistServiceClient.GetPlaylist(ctx, &api.GetPlaylistRequest{
+		PlaylistID: playlistID,
+		UserToken:  serializedToken,
+	})
+	if err != nil {
+		return err
+	}
+
+	items := make(map[string]struct{}, len(playlist.PlaylistItems))
+	for _, item := range playlist.PlaylistItems {
+		items[item.PlaylistItemID] = struct{}{}
+	}
+	for _, playlistItemID := range playlistItemIDs {
+		if _, ok := items[playlistItemID]; !ok {
+			return status.Errorf(codes.InvalidArgument, "playlist item %s is not in playlist %s", playlistItemID, playlistID)
+		}
+	}
+	return nil
+}
+
+type playlistUpdateOperation struct {
+	path   string
+	target string
+	// apply returns ID of added playlist item
+	apply func() (string, error)
 }
 
-func convertToPlaylistAPIGateway(playlist *api.Playlist) *apigateway.Playlist {
-	return &apigateway.Playlist{
-		PlaylistID:         playlist.PlaylistID,
-		Name:               playlist.Name,
-		OwnerID:            playlist.OwnerID,
-		CreatedAtTimestamp: playlist.CreatedAtTimestamp,
-		UpdatedAtTimestamp: playlist.UpdatedAtTimestamp,
-		PlaylistItems:      convertToPlaylistItemsAPIGateway(playlist.PlaylistItems),
+// updatePlaylistPaths returns unique paths of update mask in their order or paths of non-empty fields for empty mask
+func updatePlaylistPaths(req *apigateway.UpdatePlaylistRequest) ([]string, error) {
+	if len(req.UpdateMask.GetPaths()) == 0 {
+		var paths []string
+		if req.Name != "" {
+			paths = append(paths, updatePlaylistNamePath)
+		}
+		if len(req.AddedContentIDs) != 0 {
+			paths = append(paths, updatePlaylistAddedContentIDsPath)
+		}
+		if len(req.RemovedPlaylistItemIDs) != 0 {
+			paths = append(paths, updatePlaylistRemovedPlaylistItemIDsPath)
+		}
+		return paths, nil
+	}
+
+	for _, path := range req.UpdateMask.Paths {
+		switch path {
+		case updatePlaylistNamePath, updatePlaylistAddedContentIDsPath, updatePlaylistRemovedPlaylistItemIDsPath:
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "unknown update mask path %q", path)
+		}
+	}
+	return uniqueStrings(req.UpdateMask.Paths), nil
+}
+
+// convertToPlaylistsAPIGateway converts playlists with fields selected by mask, items are not converted unless selected
+func convertToPlaylistsAPIGateway(playlists []*api.Playlist, mask fieldmask.Mask) []*apigateway.Playlist {
+	result := make([]*apigateway.Playlist, len(playlists))
+	for i, playlist := range playlists {
+		result[i] = &apigateway.Playlist{
+			PlaylistID:         playlist.PlaylistID,
+			Name:               playlist.Name,
+			OwnerID:            playlist.OwnerID,
+			CreatedAtTimestamp: playlist.CreatedAtTimestamp,
+			UpdatedAtTimestamp: playlist.UpdatedAtTimestamp,
+		}
+		if mask.Includes(playlistItemsField) {
+			result[i].PlaylistItems = convertToPlaylistItemsAPIGateway(playlist.PlaylistItems)
+		}
+		mask.Prune(result[i])
 	}
+	return result
 }
 
 func convertToPlaylistItemsAPIGateway(playlistItems []*api.PlaylistItem) []*apigateway.PlaylistItem {
@@ -0,0 +1,181 @@
+package apiserver
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	"apigateway/api/apigateway"
+	contentserviceapi "apigateway/api/contentservice"
+	api "apigateway/api/playlistservice"
+)
+
+func TestUpdatePlaylist(t *testing.T) {
+	testCases := []struct {
+		name     string
+		req      *apigateway.UpdatePlaylistRequest
+		failures map[string]error
+		wantCode codes.Code
+		// wantResults are formatted as "path target status"
+		wantResults []string
+		wantName    string
+		wantItems   []string
+	}{
+		{
+			name: "mask order",
+			req: &apigateway.UpdatePlaylistRequest{
+				Name:                   "renamed",
+				AddedContentIDs:        []string{"c3"},
+				RemovedPlaylistItemIDs: []string{"p1-i1"},
+				UpdateMask:             &fieldmaskpb.FieldMask{Paths: []string{"removedPlaylistItemIDs", "name", "addedContentIDs", "name"}},
+			},
+			wantResults: []string{
+				"removedPlaylistItemIDs p1-i1 Applied",
+				"name renamed Applied",
+				"addedContentIDs c3 Applied",
+			},
+			wantName:  "renamed",
+			wantItems: []string{"c2", "c3"},
+		},
+		{
+			name: "empty mask selects non-empty fields",
+			req: &apigateway.UpdatePlaylistRequest{
+				AddedContentIDs:        []string{"c3"},
+				RemovedPlaylistItemIDs: []string{"p1-i2"},
+			},
+			wantResults: []string{
+				"addedContentIDs c3 Applied",
+				"removedPlaylistItemIDs p1-i2 Applied",
+			},
+			wantName:  "initial",
+			wantItems: []string{"c1", "c3"},
+		},
+		{
+			name: "operations after failure are skipped",
+			req: &apigateway.UpdatePlaylistRequest{
+				Name:            "renamed",
+				AddedContentIDs: []string{"c3", "c4", "c5"},
+				UpdateMask:      &fieldmaskpb.FieldMask{Paths: []string{"addedContentIDs", "name"}},
+			},
+			failures: map[string]error{"AddToPlaylist c4": status.Error(codes.Unavailable, "playlist service is unavailable")},
+			wantResults: []string{
+				"addedContentIDs c3 Applied",
+				"addedContentIDs c4 Failed",
+				"addedContentIDs c5 Skipped",
+				"name renamed Skipped",
+			},
+			wantName:  "initial",
+			wantItems: []string{"c1", "c2", "c3"},
+		},
+		{
+			name: "unknown mask path",
+			req: &apigateway.UpdatePlaylistRequest{
+				Name:       "renamed",
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name", "ownerID"}},
+			},
+			wantCode:  codes.InvalidArgument,
+			wantName:  "initial",
+			wantItems: []string{"c1", "c2"},
+		},
+		{
+			name: "item of other playlist",
+			req: &apigateway.UpdatePlaylistRequest{
+				Name:                   "renamed",
+				RemovedPlaylistItemIDs: []string{"p1-i1", "p2-i1"},
+				UpdateMask:             &fieldmaskpb.FieldMask{Paths: []string{"name", "removedPlaylistItemIDs"}},
+			},
+			wantCode:  codes.InvalidArgument,
+			wantName:  "initial",
+			wantItems: []string{"c1", "c2"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			client := newFakePlaylistClient(testCase.failures)
+			playlistID := client.addPlaylist("initial", "c1", "c2")
+			otherPlaylistID := client.addPlaylist("other", "c9")
+			server := newTestServer(client)
+
+			testCase.req.PlaylistID = playlistID
+			resp, err := server.UpdatePlaylist(testUserContext(), testCase.req)
+			if status.Code(err) != testCase.wantCode {
+				t.Fatalf("got error %v, want %v", err, testCase.wantCode)
+			}
+
+			var results []string
+			for _, result := range resp.GetResults() {
+				results = append(results, fmt.Sprintf("%s %s %v", result.Path, result.Target, result.Status))
+			}
+			if strings.Join(results, "; ") != strings.Join(testCase.wantResults, "; ") {
+				t.Errorf("got results %v, want %v", results, testCase.wantResults)
+			}
+
+			playlist, err := client.GetPlaylist(testUserContext(), &api.GetPlaylistRequest{PlaylistID: playlistID})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if playlist.Name != testCase.wantName {
+				t.Errorf("got name %q, want %q", playlist.Name, testCase.wantName)
+			}
+			if items := client.contentIDs(playlistID); strings.Join(items, ",") != strings.Join(testCase.wantItems, ",") {
+				t.Errorf("got playlist items %v, want %v", items, testCase.wantItems)
+			}
+			if items := client.contentIDs(otherPlaylistID); len(items) != 1 {
+				t.Errorf("got items %v of other playlist, want it unchanged", items)
+			}
+		})
+	}
+}
+
+func TestGetPlaylistDetailedMarksNotLoadedContents(t *testing.T) {
+	// Second chunk fails, first one has deleted and private contents
+	contentIDs := []string{"deleted", "private"}
+	contents := map[string]*contentserviceapi.Content{
+		"private": {ContentID: "private", AuthorID: "other", AvailabilityType: contentserviceapi.ContentAvailabilityType_Private},
+	}
+	for len(contentIDs) < contentListChunkSize {
+		contentID := fmt.Sprintf("c%d", len(contentIDs))
+		contentIDs = append(contentIDs, contentID)
+		contents[contentID] = &contentserviceapi.Content{ContentID: contentID, AvailabilityType: contentserviceapi.ContentAvailabilityType_Public}
+	}
+	contentIDs = append(contentIDs, "failed1", "failed2")
+
+	client := newFakePlaylistClient(nil)
+	playlistID := client.addPlaylist("detailed", contentIDs...)
+	server := newTestServer(client)
+	server.contentServiceClient = &fakeContentClient{
+		contents:  contents,
+		failedIDs: map[string]struct{}{"failed1": {}},
+	}
+
+	resp, err := server.GetPlaylistDetailed(testUserContext(), &apigateway.GetPlaylistDetailedRequest{PlaylistID: playlistID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(resp.PlaylistItems) != len(contentIDs) {
+		t.Fatalf("got %d items, want %d", len(resp.PlaylistItems), len(contentIDs))
+	}
+	for i, item := range resp.PlaylistItems {
+		want := apigateway.PlaylistItemContentStatus_Available
+		switch contentIDs[i] {
+		case "deleted":
+			want = apigateway.PlaylistItemContentStatus_Deleted
+		case "private":
+			want = apigateway.PlaylistItemContentStatus_Unavailable
+		case "failed1", "failed2":
+			want = apigateway.PlaylistItemContentStatus_NotLoaded
+		}
+		if item.ContentID != contentIDs[i] || item.ContentStatus != want {
+			t.Errorf("item %d: got %s %v, want %s %v", i, item.ContentID, item.ContentStatus, contentIDs[i], want)
+		}
+		if (item.Content != nil) != (want == apigateway.PlaylistItemContentStatus_Available) {
+			t.Errorf("item %d: got content %v with status %v", i, item.Content, item.ContentStatus)
+		}
+	}
+}
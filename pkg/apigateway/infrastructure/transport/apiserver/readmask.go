@@ -0,0 +1,19 @@
+package apiserver
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	"apigateway/pkg/apigateway/infrastructure/fieldmask"
+)
+
+// newReadMask validates read mask of request against message it selects fields of, nil read mask selects all fields
+func newReadMask(message proto.Message, readMask *fieldmaskpb.FieldMask) (fieldmask.Mask, error) {
+	mask, err := fieldmask.New(message, readMask.GetPaths())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return mask, nil
+}
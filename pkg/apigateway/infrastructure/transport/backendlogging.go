@@ -0,0 +1,115 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/CuriosityMusicStreaming/ComponentsPool/pkg/app/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"apigateway/pkg/apigateway/infrastructure/auth"
+	"apigateway/pkg/apigateway/infrastructure/interceptor"
+	"apigateway/pkg/apigateway/infrastructure/logging"
+)
+
+const authorizationMetadataKey = "authorization"
+
+// NewBackendLoggerClientInterceptor returns interceptor that logs backend calls with redacted request and response
+// when log level is debug or debug session matches user or activity of incoming call
+func NewBackendLoggerClientInterceptor(
+	logger logging.LevelLogger,
+	redactor Redactor,
+	debugSessions logging.DebugSessions,
+	authenticationService auth.AuthenticationService,
+) interceptor.Client {
+	b := &backendLogger{
+		logger:                logger,
+		debugSessions:         debugSessions,
+		authenticationService: authenticationService,
+	}
+
+	return interceptor.Client{
+		Unary: func(ctx context.Context, fullMethod string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			entry, ok := b.debugEntry(ctx, fullMethod)
+			if !ok {
+				return invoker(ctx, fullMethod, req, reply, cc, opts...)
+			}
+
+			start := time.Now()
+			err := invoker(ctx, fullMethod, req, reply, cc, opts...)
+
+			entry = entry.WithFields(log.Fields{
+				"args":     redactor.Redact(req),
+				"duration": fmt.Sprintf("%v", time.Since(start)),
+			})
+			if err != nil {
+				entry.Error(err, "backend call failed")
+			} else {
+				entry.WithField("result", redactor.Redact(reply)).Info("backend call finished")
+			}
+			return err
+		},
+		// Stream messages are not logged, only stream start
+		Stream: func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, fullMethod string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			entry, ok := b.debugEntry(ctx, fullMethod)
+			if !ok {
+				return streamer(ctx, desc, cc, fullMethod, opts...)
+			}
+
+			stream, err := streamer(ctx, desc, cc, fullMethod, opts...)
+			if err != nil {
+				entry.Error(err, "backend stream failed")
+			} else {
+				entry.Info("backend stream started")
+			}
+			return stream, err
+		},
+	}
+}
+
+type backendLogger struct {
+	logger                logging.LevelLogger
+	debugSessions         logging.DebugSessions
+	authenticationService auth.AuthenticationService
+}
+
+// debugEntry returns logger with call fields if call must be logged
+func (b *backendLogger) debugEntry(ctx context.Context, fullMethod string) (log.Logger, bool) {
+	activityID := ""
+	if id, ok := ActivityIDFromContext(ctx); ok {
+		activityID = id.String()
+	}
+	userID := b.userID(ctx)
+
+	fields := log.Fields{
+		"activityID":    activityID,
+		"backendMethod": fullMethod,
+	}
+	if userID != "" {
+		fields["userID"] = userID
+	}
+
+	if b.debugSessions.Enabled(userID, activityID) {
+		return b.logger.Unfiltered().WithFields(fields), true
+	}
+	if b.logger.Level() == logging.LevelDebug {
+		return b.logger.WithFields(fields), true
+	}
+	return nil, false
+}
+
+// userID takes user of incoming call from authorization metadata, backend requests carry only serialized user token
+func (b *backendLogger) userID(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	headers := md.Get(authorizationMetadataKey)
+	if len(headers) == 0 {
+		return ""
+	}
+	userDescriptor, err := b.authenticationService.ReceiveUserID(headers[0])
+	if err != nil {
+		return ""
+	}
+	return userDescriptor.UserID.String()
+}
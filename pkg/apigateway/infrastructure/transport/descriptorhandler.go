@@ -0,0 +1,45 @@
+package transport
+
+import (
+	"net/http"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const protobufContentType = "application/octet-stream"
+
+// NewFileDescriptorSetHandler returns handler serving binary FileDescriptorSet of file with all its dependencies,
+// the same format as produced by protoc --descriptor_set_out --include_imports
+func NewFileDescriptorSetHandler(file protoreflect.FileDescriptor) (http.Handler, error) {
+	set := &descriptorpb.FileDescriptorSet{}
+	collectFileDescriptors(file, set, map[string]struct{}{})
+
+	body, err := proto.Marshal(set)
+	if err != nil {
+		return nil, err
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", protobufContentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="`+string(file.Package())+`.protoset"`)
+		_, _ = w.Write(body)
+	}), nil
+}
+
+// collectFileDescriptors adds dependencies before file itself, so set can be loaded in order
+func collectFileDescriptors(file protoreflect.FileDescriptor, set *descriptorpb.FileDescriptorSet, visited map[string]struct{}) {
+	if _, ok := visited[file.Path()]; ok {
+		return
+	}
+	visited[file.Path()] = struct{}{}
+
+	imports := file.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		collectFileDescriptors(imports.Get(i).FileDescriptor, set, visited)
+	}
+
+	set.File = append(set.File, protodesc.ToFileDescriptorProto(file))
+}
@@ -0,0 +1,26 @@
+package transport
+
+import (
+	"net/http"
+)
+
+const (
+	fieldsQueryParameter   = "fields"
+	readMaskQueryParameter = "readMask"
+)
+
+// FieldsQueryParameterHandler passes ?fields= of REST request as readMask of request message,
+// explicit readMask takes precedence
+func FieldsQueryParameterHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if fields, ok := query[fieldsQueryParameter]; ok {
+			if _, ok := query[readMaskQueryParameter]; !ok {
+				query[readMaskQueryParameter] = fields
+			}
+			query.Del(fieldsQueryParameter)
+			r.URL.RawQuery = query.Encode()
+		}
+		next.ServeHTTP(w, r)
+	})
+}
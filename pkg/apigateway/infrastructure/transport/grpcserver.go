@@ -0,0 +1,65 @@
+package transport
+
+import (
+	"net"
+	"time"
+
+	log "github.com/CuriosityMusicStreaming/ComponentsPool/pkg/app/logger"
+	"github.com/CuriosityMusicStreaming/ComponentsPool/pkg/infrastructure/server"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+)
+
+type GrpcServerConfig struct {
+	ServeAddress string
+	StopTimeout  time.Duration
+}
+
+// NewGrpcServer returns server that gracefully stops grpc server and forcibly closes connections after StopTimeout
+func NewGrpcServer(baseServer *grpc.Server, config GrpcServerConfig, logger log.Logger) server.Server {
+	return &grpcServer{
+		baseServer: baseServer,
+		config:     config,
+		logger:     logger,
+	}
+}
+
+type grpcServer struct {
+	baseServer *grpc.Server
+	config     GrpcServerConfig
+	logger     log.Logger
+}
+
+func (s *grpcServer) Serve() error {
+	listener, err := net.Listen("tcp", s.config.ServeAddress)
+	if err != nil {
+		return errors.Wrapf(err, "failed to listen port %s", s.config.ServeAddress)
+	}
+
+	s.logger.Info("GRPC Server started")
+	err = s.baseServer.Serve(listener)
+	return errors.Wrap(err, "failed to serve GRPC")
+}
+
+func (s *grpcServer) Stop() error {
+	stopGrpcServer(s.baseServer, s.config.StopTimeout, s.logger)
+	return nil
+}
+
+func stopGrpcServer(baseServer *grpc.Server, timeout time.Duration, logger log.Logger) {
+	stopped := make(chan struct{})
+	go func() {
+		baseServer.GracefulStop()
+		close(stopped)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-stopped:
+	case <-timer.C:
+		logger.Info("GRPC Server graceful stop timed out, closing connections")
+		baseServer.Stop()
+	}
+}
@@ -0,0 +1,68 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	log "github.com/CuriosityMusicStreaming/ComponentsPool/pkg/app/logger"
+	"github.com/CuriosityMusicStreaming/ComponentsPool/pkg/infrastructure/server"
+	"github.com/pkg/errors"
+)
+
+const (
+	httpReadTimeout  = 15 * time.Second
+	httpWriteTimeout = 15 * time.Second
+)
+
+type HTTPServerConfig struct {
+	ServeAddress    string
+	ShutdownTimeout time.Duration
+	// TLSCertFile and TLSKeyFile enable TLS when both are set
+	TLSCertFile string
+	TLSKeyFile  string
+}
+
+// NewHTTPServer returns server that shuts down http server and forcibly closes connections after ShutdownTimeout
+func NewHTTPServer(handler http.Handler, config HTTPServerConfig, logger log.Logger) server.Server {
+	return &httpServer{
+		baseServer: &http.Server{
+			Handler:      handler,
+			Addr:         config.ServeAddress,
+			WriteTimeout: httpWriteTimeout,
+			ReadTimeout:  httpReadTimeout,
+		},
+		config: config,
+		logger: logger,
+	}
+}
+
+type httpServer struct {
+	baseServer *http.Server
+	config     HTTPServerConfig
+	logger     log.Logger
+}
+
+func (s *httpServer) Serve() error {
+	s.logger.WithField("address", s.config.ServeAddress).Info("HTTP server started")
+
+	var err error
+	if s.config.TLSCertFile != "" && s.config.TLSKeyFile != "" {
+		err = s.baseServer.ListenAndServeTLS(s.config.TLSCertFile, s.config.TLSKeyFile)
+	} else {
+		err = s.baseServer.ListenAndServe()
+	}
+	return errors.Wrap(err, "failed to serve HTTP")
+}
+
+func (s *httpServer) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
+	defer cancel()
+
+	err := s.baseServer.Shutdown(ctx)
+	if err == context.DeadlineExceeded {
+		s.logger.Info("HTTP server shutdown timed out, closing connections")
+		return s.baseServer.Close()
+	}
+	return err
+}
@@ -0,0 +1,62 @@
+package transport
+
+import (
+	"context"
+	"net"
+	"time"
+
+	log "github.com/CuriosityMusicStreaming/ComponentsPool/pkg/app/logger"
+	"github.com/CuriosityMusicStreaming/ComponentsPool/pkg/infrastructure/server"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const (
+	InProcessEndpoint = "inprocess"
+
+	inProcessBufferSize = 1024 * 1024
+)
+
+// InProcessGrpcServer serves grpc server over in-memory connection,
+// so REST gateway calls pass through the same interceptors without network round trip
+type InProcessGrpcServer interface {
+	server.Server
+	DialOptions() []grpc.DialOption
+}
+
+func NewInProcessGrpcServer(baseServer *grpc.Server, stopTimeout time.Duration, logger log.Logger) InProcessGrpcServer {
+	return &inProcessGrpcServer{
+		baseServer:  baseServer,
+		listener:    bufconn.Listen(inProcessBufferSize),
+		stopTimeout: stopTimeout,
+		logger:      logger,
+	}
+}
+
+type inProcessGrpcServer struct {
+	baseServer  *grpc.Server
+	listener    *bufconn.Listener
+	stopTimeout time.Duration
+	logger      log.Logger
+}
+
+func (s *inProcessGrpcServer) Serve() error {
+	err := s.baseServer.Serve(s.listener)
+	return errors.Wrap(err, "failed to serve in-process GRPC")
+}
+
+// Stop gracefully stops base server, it returns immediately if base server is already stopped by network server
+func (s *inProcessGrpcServer) Stop() error {
+	stopGrpcServer(s.baseServer, s.stopTimeout, s.logger)
+	return s.listener.Close()
+}
+
+func (s *inProcessGrpcServer) DialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithInsecure(),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return s.listener.Dial()
+		}),
+	}
+}
@@ -0,0 +1,95 @@
+package transport
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	log "github.com/CuriosityMusicStreaming/ComponentsPool/pkg/app/logger"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"google.golang.org/grpc"
+
+	"apigateway/api/apigateway"
+)
+
+const benchmarkAuthRequestBody = `{"email":"user@example.com","password":"password"}`
+
+// BenchmarkRESTGatewayLoopback measures REST call of grpc server dialed over loopback TCP, see REST_GATEWAY_MODE
+func BenchmarkRESTGatewayLoopback(b *testing.B) {
+	baseServer := newBenchmarkGrpcServer()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		b.Fatal(err)
+	}
+	go func() {
+		_ = baseServer.Serve(listener)
+	}()
+	defer baseServer.Stop()
+
+	benchmarkRESTGateway(b, listener.Addr().String(), []grpc.DialOption{grpc.WithInsecure()})
+}
+
+// BenchmarkRESTGatewayInProcess measures REST call of grpc server through in-memory connection
+func BenchmarkRESTGatewayInProcess(b *testing.B) {
+	baseServer := newBenchmarkGrpcServer()
+	inProcessServer := NewInProcessGrpcServer(baseServer, time.Second, nopLogger{})
+	go func() {
+		_ = inProcessServer.Serve()
+	}()
+	defer func() {
+		_ = inProcessServer.Stop()
+	}()
+
+	benchmarkRESTGateway(b, InProcessEndpoint, inProcessServer.DialOptions())
+}
+
+func benchmarkRESTGateway(b *testing.B, endpoint string, opts []grpc.DialOption) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gatewayMux := runtime.NewServeMux()
+	err := apigateway.RegisterAPIGatewayHandlerFromEndpoint(ctx, gatewayMux, endpoint, opts)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth", strings.NewReader(benchmarkAuthRequestBody))
+			recorder := httptest.NewRecorder()
+			gatewayMux.ServeHTTP(recorder, req)
+			if recorder.Code != http.StatusOK {
+				b.Errorf("unexpected status %d: %s", recorder.Code, recorder.Body.String())
+				return
+			}
+		}
+	})
+}
+
+func newBenchmarkGrpcServer() *grpc.Server {
+	baseServer := grpc.NewServer()
+	apigateway.RegisterAPIGatewayServer(baseServer, &stubAPIGatewayServer{})
+	return baseServer
+}
+
+// stubAPIGatewayServer answers without backends, so benchmarks measure only transport
+type stubAPIGatewayServer struct {
+	apigateway.UnimplementedAPIGatewayServer
+}
+
+func (*stubAPIGatewayServer) AuthenticateUser(context.Context, *apigateway.AuthenticateUserRequest) (*apigateway.AuthenticateUserResponse, error) {
+	return &apigateway.AuthenticateUserResponse{UserID: "3f2504e0-4f89-11d3-9a0c-0305e82c3301"}, nil
+}
+
+type nopLogger struct{}
+
+func (l nopLogger) WithField(string, interface{}) log.Logger { return l }
+func (l nopLogger) WithFields(log.Fields) log.Logger         { return l }
+func (nopLogger) Info(...interface{})                        {}
+func (nopLogger) Error(error, ...interface{})                {}
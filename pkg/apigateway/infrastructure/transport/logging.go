@@ -8,41 +8,77 @@ import (
 
 	log "github.com/CuriosityMusicStreaming/ComponentsPool/pkg/app/logger"
 	"github.com/CuriosityMusicStreaming/ComponentsPool/pkg/infrastructure/activity"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
+
+	"apigateway/pkg/apigateway/infrastructure/interceptor"
 )
 
-func NewLoggerServerInterceptor(logger log.Logger) grpc.UnaryServerInterceptor {
-	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
-		activityID := activity.NewActivityID()
-		md := metadata.New(map[string]string{"activityID": activityID.String()})
+func NewLoggerServerInterceptor(logger log.Logger, redactor Redactor) interceptor.Server {
+	return interceptor.Server{
+		Unary: func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			ctx, activityID := startActivity(ctx)
+			start := time.Now()
+
+			resp, err := handler(ctx, req)
+
+			fields := callLogFields(ctx, activityID, info.FullMethod, start)
+			fields["args"] = redactor.Redact(req)
+			logCall(logger, fields, err)
+			return resp, err
+		},
+		// Stream messages are not logged, only stream result
+		Stream: func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			ctx, activityID := startActivity(stream.Context())
+			start := time.Now()
 
-		oldMd, _ := metadata.FromOutgoingContext(ctx)
-		ctx = metadata.NewOutgoingContext(ctx, metadata.Join(oldMd, md))
+			err := handler(srv, interceptor.WrapServerStream(ctx, stream))
+
+			logCall(logger, callLogFields(ctx, activityID, info.FullMethod, start), err)
+			return err
+		},
+	}
+}
 
-		start := time.Now()
+// startActivity stores activity ID in context, passes it to backends in outgoing metadata and returns it to caller in response header
+func startActivity(ctx context.Context) (context.Context, activity.ID) {
+	activityID := receiveActivityID(ctx)
+	ctx = withActivityID(ctx, activityID)
+	md := metadata.New(map[string]string{activityIDMetadataKey: activityID.String()})
 
-		resp, err = handler(ctx, req)
+	// SetHeader fails only when headers are already sent, so error is ignored
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadataKey, activityID.String()))
 
-		fields := log.Fields{
-			"activityID": activityID.String(),
-			"args":       req,
-			"duration":   fmt.Sprintf("%v", time.Since(start)),
-			"method":     getGRPCMethodName(info),
-		}
+	oldMd, _ := metadata.FromOutgoingContext(ctx)
+	ctx = metadata.NewOutgoingContext(ctx, metadata.Join(oldMd, md))
 
-		entry := logger.WithFields(fields)
-		if err != nil {
-			entry.Error(err, "call failed")
-		} else {
-			entry.Info("call finished")
-		}
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("activity.id", activityID.String()))
+	return ctx, activityID
+}
+
+func callLogFields(ctx context.Context, activityID activity.ID, fullMethod string, start time.Time) log.Fields {
+	fields := log.Fields{
+		"activityID": activityID.String(),
+		"duration":   fmt.Sprintf("%v", time.Since(start)),
+		"method":     getGRPCMethodName(fullMethod),
+	}
+	if spanContext := trace.SpanFromContext(ctx).SpanContext(); spanContext.HasTraceID() {
+		fields["traceID"] = spanContext.TraceID().String()
+	}
+	return fields
+}
 
-		return resp, err
+func logCall(logger log.Logger, fields log.Fields, err error) {
+	entry := logger.WithFields(fields)
+	if err != nil {
+		entry.Error(err, "call failed")
+	} else {
+		entry.Info("call finished")
 	}
 }
 
-func getGRPCMethodName(info *grpc.UnaryServerInfo) string {
-	method := info.FullMethod
-	return method[strings.LastIndex(method, "/")+1:]
+func getGRPCMethodName(fullMethod string) string {
+	return fullMethod[strings.LastIndex(fullMethod, "/")+1:]
 }
@@ -0,0 +1,61 @@
+package transport
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/improbable-eng/grpc-web/go/grpcweb"
+	"google.golang.org/grpc"
+)
+
+const (
+	grpcContentType = "application/grpc"
+	anyOrigin       = "*"
+)
+
+// newMultiplexHandler routes requests served on single listener by protocol and content type:
+// gRPC-Web requests go to wrapped grpc server, HTTP/2 gRPC requests go to grpc server and others go to restHandler.
+// Server has no read and write timeouts, so they do not cut grpc streams, REST requests get them as deadlines
+func newMultiplexHandler(grpcServer *grpc.Server, restHandler http.Handler, allowedOrigins []string) http.Handler {
+	grpcWebServer := grpcweb.WrapServer(grpcServer, grpcweb.WithOriginFunc(newOriginMatcher(allowedOrigins)))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case grpcWebServer.IsGrpcWebRequest(r) || grpcWebServer.IsAcceptableGrpcCorsRequest(r):
+			grpcWebServer.ServeHTTP(w, r)
+		case isGRPCRequest(r):
+			grpcServer.ServeHTTP(w, r)
+		default:
+			setRESTDeadlines(w)
+			restHandler.ServeHTTP(w, r)
+		}
+	})
+}
+
+// setRESTDeadlines applies timeouts of REST server, errors are ignored as deadlines are best effort
+func setRESTDeadlines(w http.ResponseWriter) {
+	controller := http.NewResponseController(w)
+	now := time.Now()
+	_ = controller.SetReadDeadline(now.Add(httpReadTimeout))
+	_ = controller.SetWriteDeadline(now.Add(httpWriteTimeout))
+}
+
+func isGRPCRequest(r *http.Request) bool {
+	return r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), grpcContentType)
+}
+
+func newOriginMatcher(allowedOrigins []string) func(origin string) bool {
+	origins := make(map[string]struct{}, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		origins[origin] = struct{}{}
+	}
+
+	return func(origin string) bool {
+		if _, ok := origins[anyOrigin]; ok {
+			return true
+		}
+		_, ok := origins[origin]
+		return ok
+	}
+}
@@ -0,0 +1,159 @@
+package transport
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/CuriosityMusicStreaming/ComponentsPool/pkg/app/logger"
+	"github.com/CuriosityMusicStreaming/ComponentsPool/pkg/infrastructure/server"
+	"github.com/pkg/errors"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+)
+
+const (
+	hijackedConnsPollInterval = 100 * time.Millisecond
+)
+
+type MultiplexServerConfig struct {
+	ServeAddress    string
+	ShutdownTimeout time.Duration
+	AllowedOrigins  []string
+	// TLSCertFile and TLSKeyFile enable TLS when both are set
+	TLSCertFile string
+	TLSKeyFile  string
+}
+
+// NewMultiplexServer serves grpc, gRPC-Web and REST on single listener, HTTP/2 is accepted both over TLS and cleartext (h2c).
+// grpcServer serves requests through ServeHTTP, which does not support graceful stop,
+// so Stop shuts down http server first and then stops grpcServer. Servers using grpcServer must be stopped after this one
+func NewMultiplexServer(grpcServer *grpc.Server, restHandler http.Handler, config MultiplexServerConfig, logger log.Logger) server.Server {
+	s := &multiplexServer{
+		grpcServer:    grpcServer,
+		http2Server:   &http2.Server{},
+		config:        config,
+		logger:        logger,
+		hijackedConns: &hijackedConns{conns: map[net.Conn]struct{}{}},
+	}
+
+	handler := h2c.NewHandler(newMultiplexHandler(grpcServer, restHandler, config.AllowedOrigins), s.http2Server)
+
+	s.baseServer = &http.Server{
+		Addr: config.ServeAddress,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handler.ServeHTTP(w, r)
+			// h2c handler returns when hijacked connection is closed
+			if conn, ok := r.Context().Value(connContextKey{}).(net.Conn); ok {
+				s.hijackedConns.remove(conn)
+			}
+		}),
+		ReadHeaderTimeout: httpReadTimeout,
+		ConnContext: func(ctx context.Context, conn net.Conn) context.Context {
+			return context.WithValue(ctx, connContextKey{}, conn)
+		},
+		ConnState: func(conn net.Conn, state http.ConnState) {
+			if state == http.StateHijacked {
+				s.hijackedConns.add(conn)
+			}
+		},
+	}
+	return s
+}
+
+type multiplexServer struct {
+	baseServer    *http.Server
+	http2Server   *http2.Server
+	grpcServer    *grpc.Server
+	config        MultiplexServerConfig
+	logger        log.Logger
+	hijackedConns *hijackedConns
+}
+
+type connContextKey struct{}
+
+func (s *multiplexServer) Serve() error {
+	// Shares connection state with h2c handler, so Shutdown sends GOAWAY to h2c connections too
+	err := http2.ConfigureServer(s.baseServer, s.http2Server)
+	if err != nil {
+		return errors.Wrap(err, "failed to configure HTTP/2")
+	}
+
+	s.logger.WithField("address", s.config.ServeAddress).Info("multiplex server started")
+
+	if s.config.TLSCertFile != "" && s.config.TLSKeyFile != "" {
+		err = s.baseServer.ListenAndServeTLS(s.config.TLSCertFile, s.config.TLSKeyFile)
+	} else {
+		err = s.baseServer.ListenAndServe()
+	}
+	return errors.Wrap(err, "failed to serve multiplex server")
+}
+
+// Stop waits for requests and h2c connections for ShutdownTimeout and then closes them.
+// After that grpc server is stopped without graceful stop
+func (s *multiplexServer) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
+	defer cancel()
+
+	// http server does not track hijacked connections, so they are waited for separately
+	err := s.baseServer.Shutdown(ctx)
+	if err == nil {
+		err = s.hijackedConns.wait(ctx)
+	}
+	if err == context.DeadlineExceeded {
+		s.logger.Info("multiplex server shutdown timed out, closing connections")
+		err = s.baseServer.Close()
+		s.hijackedConns.close()
+	}
+
+	s.grpcServer.Stop()
+	return err
+}
+
+// hijackedConns are connections taken over by h2c handler
+type hijackedConns struct {
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+}
+
+func (c *hijackedConns) add(conn net.Conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conns[conn] = struct{}{}
+}
+
+func (c *hijackedConns) remove(conn net.Conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.conns, conn)
+}
+
+func (c *hijackedConns) empty() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.conns) == 0
+}
+
+func (c *hijackedConns) wait(ctx context.Context) error {
+	ticker := time.NewTicker(hijackedConnsPollInterval)
+	defer ticker.Stop()
+	for !c.empty() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+func (c *hijackedConns) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for conn := range c.conns {
+		_ = conn.Close()
+	}
+}
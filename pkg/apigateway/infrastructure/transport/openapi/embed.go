@@ -0,0 +1,11 @@
+package openapi
+
+import (
+	_ "embed" // enables go:embed
+)
+
+//go:embed apigateway.swagger.json
+var apiGatewaySpec []byte
+
+//go:embed index.html
+var indexTemplate string
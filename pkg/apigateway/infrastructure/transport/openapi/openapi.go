@@ -0,0 +1,129 @@
+package openapi
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/pkg/errors"
+	swaggerFiles "github.com/swaggo/files"
+)
+
+const (
+	Version2 = "2"
+	Version3 = "3"
+
+	authorizationHeaderName = "Authorization"
+	jsonContentType         = "application/json"
+)
+
+var ErrUnknownVersion = errors.New("unknown openapi version")
+
+type Config struct {
+	// ServerURL is external gateway url like https://api.example.com, request host is used when empty
+	ServerURL string
+	// AuthType is scheme of authorization header, e.g. Bearer
+	AuthType string
+	Version  string
+}
+
+// NewSpecHandler returns handler serving APIGateway spec generated by protoc-gen-swagger with server url and auth scheme from config
+func NewSpecHandler(config Config) (http.Handler, error) {
+	spec, err := buildSpec(config)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", jsonContentType)
+		_, _ = w.Write(body)
+	}), nil
+}
+
+// NewDocsHandler returns handler serving Swagger UI bundled into binary, prefix is path handler mounted to
+func NewDocsHandler(prefix, specURL string) (http.Handler, error) {
+	index, err := template.New("index").Parse(indexTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	fileServer := http.StripPrefix(prefix, http.FileServer(swaggerFiles.HTTP))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case prefix:
+			_ = index.Execute(w, struct{ SpecURL string }{SpecURL: specURL})
+		case strings.TrimSuffix(prefix, "/"):
+			http.Redirect(w, r, prefix, http.StatusMovedPermanently)
+		default:
+			fileServer.ServeHTTP(w, r)
+		}
+	}), nil
+}
+
+func buildSpec(config Config) (interface{}, error) {
+	spec := &openapi2.T{}
+	if err := json.Unmarshal(apiGatewaySpec, spec); err != nil {
+		return nil, errors.Wrap(err, "failed to parse swagger spec")
+	}
+
+	if config.ServerURL != "" {
+		serverURL, err := url.Parse(config.ServerURL)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse server url")
+		}
+		spec.Host = serverURL.Host
+		spec.Schemes = []string{serverURL.Scheme}
+		spec.BasePath = serverURL.Path
+	}
+
+	if config.AuthType != "" {
+		spec.SecurityDefinitions = map[string]*openapi2.SecurityScheme{
+			config.AuthType: {
+				Type:        "apiKey",
+				In:          "header",
+				Name:        authorizationHeaderName,
+				Description: config.AuthType + " <token>",
+			},
+		}
+		spec.Security = openapi2.SecurityRequirements{{config.AuthType: {}}}
+	}
+
+	switch config.Version {
+	case Version2:
+		return spec, nil
+	case Version3:
+		return toV3(spec, config)
+	default:
+		return nil, errors.Wrapf(ErrUnknownVersion, "version %q", config.Version)
+	}
+}
+
+func toV3(spec *openapi2.T, config Config) (*openapi3.T, error) {
+	specV3, err := openapi2conv.ToV3(spec)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert swagger spec to openapi 3")
+	}
+
+	// OpenAPI 3 describes authorization schemes natively instead of raw header
+	if config.AuthType != "" {
+		specV3.Components.SecuritySchemes = openapi3.SecuritySchemes{
+			config.AuthType: &openapi3.SecuritySchemeRef{
+				Value: openapi3.NewSecurityScheme().
+					WithType("http").
+					WithScheme(strings.ToLower(config.AuthType)),
+			},
+		}
+	}
+	return specV3, nil
+}
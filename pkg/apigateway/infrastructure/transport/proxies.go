@@ -0,0 +1,69 @@
+package transport
+
+import (
+	"net"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// TrustedProxies are networks of proxies which append address of their client to X-Forwarded-For
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies parses CIDRs and single IP addresses
+func ParseTrustedProxies(addresses []string) (TrustedProxies, error) {
+	proxies := make(TrustedProxies, 0, len(addresses))
+	for _, address := range addresses {
+		if !strings.Contains(address, "/") {
+			ip := net.ParseIP(address)
+			if ip == nil {
+				return nil, errors.Errorf("invalid trusted proxy address %q", address)
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(address)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid trusted proxy network %q", address)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// ClientIP returns peerAddress unless peer is trusted proxy or trustedPeer is set, e.g. for REST gateway of this instance.
+// Then address is taken from X-Forwarded-For, where each proxy appends address of its client,
+// so the right-most address not belonging to trusted proxy is the one seen by the first of them
+func (p TrustedProxies) ClientIP(peerAddress string, trustedPeer bool, forwardedFor []string) string {
+	if !trustedPeer && !p.contains(peerAddress) {
+		return peerAddress
+	}
+
+	address := peerAddress
+	hops := strings.Split(strings.Join(forwardedFor, ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		address = hop
+		if !p.contains(hop) {
+			break
+		}
+	}
+	return address
+}
+
+func (p TrustedProxies) contains(address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, network := range p {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+package transport
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+type RedactionMode string
+
+const (
+	// RedactionModeMask replaces value with placeholder
+	RedactionModeMask = RedactionMode("mask")
+	// RedactionModeHash replaces value with short sha256 hash, so equal values can be matched in logs
+	RedactionModeHash = RedactionMode("hash")
+	// RedactionModeTruncate keeps only first characters of value
+	RedactionModeTruncate = RedactionMode("truncate")
+
+	redactedPlaceholder = "[REDACTED]"
+	redactedHashLength  = 16
+	redactedKeepLength  = 3
+
+	anyMessagePrefix = "*."
+)
+
+// DefaultRedactedFields hides credentials, emails and user tokens of gateway and backend messages
+var DefaultRedactedFields = []string{
+	"*.password",
+	"*.email:hash",
+	"*.userToken:truncate",
+}
+
+// Redactor hides sensitive fields of proto messages before they are logged
+type Redactor interface {
+	Redact(msg interface{}) interface{}
+}
+
+// NewRedactor accepts fields in form <full field name>[:<mode>], for example apigateway.AddUserRequest.email:hash,
+// or *.<field name>[:<mode>] to match field of any message. Mode is mask by default
+func NewRedactor(fields []string) (Redactor, error) {
+	rules := make(map[protoreflect.FullName]RedactionMode, len(fields))
+	anyMessageRules := make(map[protoreflect.Name]RedactionMode)
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		mode := RedactionModeMask
+		if i := strings.LastIndex(field, ":"); i != -1 {
+			field, mode = field[:i], RedactionMode(field[i+1:])
+		}
+
+		switch mode {
+		case RedactionModeMask, RedactionModeHash, RedactionModeTruncate:
+		default:
+			return nil, errors.Errorf("unknown redaction mode %q of field %s", mode, field)
+		}
+
+		if strings.HasPrefix(field, anyMessagePrefix) {
+			name := protoreflect.Name(strings.TrimPrefix(field, anyMessagePrefix))
+			if !name.IsValid() {
+				return nil, errors.Errorf("invalid redacted field name %q", field)
+			}
+			anyMessageRules[name] = mode
+			continue
+		}
+
+		name := protoreflect.FullName(field)
+		if !name.IsValid() {
+			return nil, errors.Errorf("invalid redacted field name %q", field)
+		}
+		rules[name] = mode
+	}
+	return &redactor{rules: rules, anyMessageRules: anyMessageRules}, nil
+}
+
+type redactor struct {
+	rules           map[protoreflect.FullName]RedactionMode
+	anyMessageRules map[protoreflect.Name]RedactionMode
+}
+
+// Redact returns redacted copy of msg, msg itself is left untouched since it is passed to handler
+func (r *redactor) Redact(msg interface{}) interface{} {
+	protoMsg, ok := msg.(proto.Message)
+	if !ok || (len(r.rules) == 0 && len(r.anyMessageRules) == 0) {
+		return msg
+	}
+
+	redacted := proto.Clone(protoMsg)
+	r.redactMessage(redacted.ProtoReflect())
+	return redacted
+}
+
+func (r *redactor) redactMessage(msg protoreflect.Message) {
+	msg.Range(func(fd protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		if mode, ok := r.fieldMode(fd); ok {
+			r.redactField(msg, fd, value, mode)
+			return true
+		}
+
+		if fd.Kind() != protoreflect.MessageKind && fd.Kind() != protoreflect.GroupKind {
+			return true
+		}
+
+		switch {
+		case fd.IsList():
+			list := value.List()
+			for i := 0; i < list.Len(); i++ {
+				r.redactMessage(list.Get(i).Message())
+			}
+		case fd.IsMap():
+			if fd.MapValue().Message() == nil {
+				return true
+			}
+			value.Map().Range(func(_ protoreflect.MapKey, v protoreflect.Value) bool {
+				r.redactMessage(v.Message())
+				return true
+			})
+		default:
+			r.redactMessage(value.Message())
+		}
+		return true
+	})
+}
+
+// fieldMode prefers rule of exact field over rule of any message
+func (r *redactor) fieldMode(fd protoreflect.FieldDescriptor) (RedactionMode, bool) {
+	if mode, ok := r.rules[fd.FullName()]; ok {
+		return mode, true
+	}
+	mode, ok := r.anyMessageRules[fd.Name()]
+	return mode, ok
+}
+
+// redactField replaces string values according to mode, fields of other kinds are cleared
+func (r *redactor) redactField(msg protoreflect.Message, fd protoreflect.FieldDescriptor, value protoreflect.Value, mode RedactionMode) {
+	if fd.Kind() != protoreflect.StringKind || fd.IsMap() {
+		msg.Clear(fd)
+		return
+	}
+
+	if fd.IsList() {
+		list := value.List()
+		for i := 0; i < list.Len(); i++ {
+			list.Set(i, protoreflect.ValueOfString(redactString(list.Get(i).String(), mode)))
+		}
+		return
+	}
+
+	msg.Set(fd, protoreflect.ValueOfString(redactString(value.String(), mode)))
+}
+
+func redactString(value string, mode RedactionMode) string {
+	switch mode {
+	case RedactionModeHash:
+		sum := sha256.Sum256([]byte(value))
+		return "sha256:" + hex.EncodeToString(sum[:])[:redactedHashLength]
+	case RedactionModeTruncate:
+		runes := []rune(value)
+		if len(runes) <= redactedKeepLength {
+			return redactedPlaceholder
+		}
+		return string(runes[:redactedKeepLength]) + "..."
+	default:
+		return redactedPlaceholder
+	}
+}